@@ -0,0 +1,93 @@
+package iamsigned
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxErrorBodyBytes bounds how much of an error response body is read
+const maxErrorBodyBytes = 64 << 10
+
+// APIError is an AWS-style error envelope ({"__type": "...", "message": "..."}) returned with a non-successful
+// status, as forwarded by many Lambda proxy integrations. See WithAWSErrorEnvelope. It unwraps to the *HTTPError of the
+// response.
+type APIError struct {
+	StatusCode int
+	// Code is the short error code (e.g. "ResourceNotFoundException")
+	Code string
+	// Message is the human-readable error message
+	Message string
+	// Type is the raw __type of the envelope (e.g. "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException")
+	Type string
+
+	response *HTTPError
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("received status code %v: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	if e.response == nil {
+		return nil
+	}
+	return e.response
+}
+
+// WithAWSErrorEnvelope parses the body of non-successful responses as an AWS error envelope, returning an *APIError
+// when it is one. Both "message" and "Message" casings are recognized, and the code is read from "code"/"Code", the
+// __type, or the X-Amzn-ErrorType header. Off by default.
+func WithAWSErrorEnvelope() Option {
+	return func(o *options) {
+		o.parseErrorEnvelope = true
+	}
+}
+
+type awsErrorEnvelope struct {
+	Type         string `json:"__type"`
+	Message      string `json:"message"`
+	MessageUpper string `json:"Message"`
+	Code         string `json:"code"`
+	CodeUpper    string `json:"Code"`
+}
+
+// parseAPIError reads an AWS error envelope from the response, returning nil if the body isn't one
+func parseAPIError(response *HTTPError) *APIError {
+	var envelope awsErrorEnvelope
+	if err := json.Unmarshal(response.Body, &envelope); err != nil {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: response.StatusCode,
+		Code:       firstNonEmpty(envelope.Code, envelope.CodeUpper, errorCodeFromType(envelope.Type), errorCodeFromType(response.Header.Get("X-Amzn-ErrorType"))),
+		Message:    firstNonEmpty(envelope.Message, envelope.MessageUpper),
+		Type:       envelope.Type,
+		response:   response,
+	}
+	if apiErr.Code == "" && apiErr.Message == "" {
+		return nil
+	}
+	return apiErr
+}
+
+// errorCodeFromType extracts the code from types like "aws.protocol#Code:http://internal.amazon.com/..."
+func errorCodeFromType(t string) string {
+	if i := strings.LastIndex(t, "#"); i >= 0 {
+		t = t[i+1:]
+	}
+	if i := strings.Index(t, ":"); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
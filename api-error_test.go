@@ -0,0 +1,58 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAWSErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name      string
+		errorType string
+		body      string
+		opts      []Option
+		code      string
+		message   string
+	}{
+		{"qualified type", "", `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"gone"}`,
+			[]Option{WithAWSErrorEnvelope()}, "ResourceNotFoundException", "gone"},
+		{"upper case", "", `{"Code":"ThrottlingException","Message":"slow down"}`,
+			[]Option{WithAWSErrorEnvelope()}, "ThrottlingException", "slow down"},
+		{"header", "AccessDeniedException:http://internal.amazon.com/coral/", `{"message":"denied"}`,
+			[]Option{WithAWSErrorEnvelope()}, "AccessDeniedException", "denied"},
+		{"not an envelope", "", `<html>oops</html>`, []Option{WithAWSErrorEnvelope()}, "", ""},
+		{"off by default", "", `{"__type":"ValidationException","message":"bad"}`, nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.errorType != "" {
+					w.Header().Set("X-Amzn-ErrorType", tt.errorType)
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet,
+				testCreds, tt.opts...)
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+				t.Errorf("got error %v, want it to unwrap to the HTTPError", err)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				if tt.code != "" {
+					t.Errorf("got error %v, want an APIError", err)
+				}
+				return
+			}
+			if apiErr.Code != tt.code || apiErr.Message != tt.message || apiErr.StatusCode != http.StatusBadRequest {
+				t.Errorf("got %+v, want code %q and message %q", apiErr, tt.code, tt.message)
+			}
+		})
+	}
+}
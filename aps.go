@@ -0,0 +1,81 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// prometheusResponse is the envelope of the Prometheus HTTP API
+type prometheusResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+}
+
+// APSRemoteWrite signs and sends a Prometheus remote-write request to Amazon Managed Service for Prometheus.
+// payload is the snappy-compressed protobuf WriteRequest, and endpoint the workspace remote-write URL (e.g.
+// https://aps-workspaces.<region>.amazonaws.com/workspaces/<id>/api/v1/remote_write). The 202 AMP may answer with is
+// successful, like any 2xx status.
+func APSRemoteWrite(ctx context.Context, payload []byte, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	o.contentType = "application/x-protobuf"
+	o.setHeader("Content-Encoding", "snappy")
+	o.setHeader("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	return deliverBytes(ctx, payload, APSService, endpoint, region, http.MethodPost, creds, o)
+}
+
+// APSQuery evaluates a PromQL instant query at t (the current time when zero) against an Amazon Managed Service for
+// Prometheus workspace, whose URL is given (e.g. https://aps-workspaces.<region>.amazonaws.com/workspaces/<id>), and
+// returns the data of the response (its resultType and result)
+func APSQuery(ctx context.Context, endpoint, query string, t time.Time, region string, creds *credentials.Credentials, opts ...Option) (json.RawMessage, error) {
+	params := url.Values{"query": {query}}
+	if !t.IsZero() {
+		params.Set("time", prometheusTime(t))
+	}
+	return apsQuery(ctx, endpoint, "/api/v1/query", params, region, creds, opts)
+}
+
+// APSQueryRange evaluates a PromQL query over the range from start to end, with a resolution of step, see APSQuery
+func APSQueryRange(ctx context.Context, endpoint, query string, start, end time.Time, step time.Duration, region string, creds *credentials.Credentials, opts ...Option) (json.RawMessage, error) {
+	params := url.Values{
+		"query": {query},
+		"start": {prometheusTime(start)},
+		"end":   {prometheusTime(end)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	return apsQuery(ctx, endpoint, "/api/v1/query_range", params, region, creds, opts)
+}
+
+// apsQuery sends a signed GET to a Prometheus API path of the workspace, and unwraps the response envelope
+func apsQuery(ctx context.Context, endpoint, path string, params url.Values, region string, creds *credentials.Credentials, opts []Option) (json.RawMessage, error) {
+	o := newOptions(opts)
+	WithQuery(params)(o)
+	o.expectJSON = true
+	data, err := deliverBytes(ctx, nil, APSService, strings.TrimSuffix(endpoint, "/")+path, region, http.MethodGet, creds, o)
+	if err != nil {
+		return nil, err
+	}
+	var response prometheusResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, o.wrapError(fmt.Errorf("could not parse response, expected JSON: '%s': %w", snippet(data), err))
+	}
+	if response.Status != "success" {
+		return nil, o.wrapError(fmt.Errorf("query failed (%s): %s", response.ErrorType, response.Error))
+	}
+	return response.Data, nil
+}
+
+// prometheusTime formats t as the Unix timestamp, in seconds, the Prometheus API takes
+func prometheusTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+}
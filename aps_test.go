@@ -0,0 +1,53 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPSRemoteWrite(t *testing.T) {
+	payload := []byte{0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'}
+	tests := []struct {
+		name    string
+		status  int
+		failure bool
+	}{
+		{"ok", http.StatusOK, false},
+		{"accepted", http.StatusAccepted, false},
+		{"rejected", http.StatusBadRequest, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				for name, want := range map[string]string{
+					"Content-Type":                      "application/x-protobuf",
+					"Content-Encoding":                  "snappy",
+					"X-Prometheus-Remote-Write-Version": "0.1.0",
+				} {
+					if got := r.Header.Get(name); got != want {
+						http.Error(w, name+" is "+got, http.StatusUnsupportedMediaType)
+						return
+					}
+				}
+				if !bytes.Equal(body, payload) || !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/aps/") {
+					http.Error(w, "bad request", http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			_, err := APSRemoteWrite(context.Background(), payload, server.URL+"/workspaces/ws-1/api/v1/remote_write",
+				"eu-west-1", testCreds)
+			if failed := err != nil; failed != tt.failure {
+				t.Errorf("got error %v, want a failure: %v", err, tt.failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package iamsigned
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// AssumeRoleOption customizes the role session AssumeRole creates
+type AssumeRoleOption func(*assumeRoleOptions)
+
+type assumeRoleOptions struct {
+	source   *credentials.Credentials
+	region   string
+	provider []func(*stscreds.AssumeRoleProvider)
+}
+
+// WithSourceCredentials assumes the role with source, instead of the default credential chain
+func WithSourceCredentials(source *credentials.Credentials) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.source = source
+	}
+}
+
+// WithSTSRegion calls the regional STS endpoint of region, instead of the region of the shared config
+func WithSTSRegion(region string) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.region = region
+	}
+}
+
+// WithExternalID passes the external ID the role trust policy requires
+func WithExternalID(id string) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.provider = append(o.provider, func(p *stscreds.AssumeRoleProvider) {
+			p.ExternalID = aws.String(id)
+		})
+	}
+}
+
+// WithRoleSessionName names the role session, e.g. to find it in CloudTrail. A random name is used by default.
+func WithRoleSessionName(name string) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.provider = append(o.provider, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = name
+		})
+	}
+}
+
+// WithRoleDuration sets how long each role session lasts (15 minutes by default)
+func WithRoleDuration(duration time.Duration) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.provider = append(o.provider, func(p *stscreds.AssumeRoleProvider) {
+			p.Duration = duration
+		})
+	}
+}
+
+// WithSessionTags tags the role session. The transitive keys are passed on to the roles chained after this one.
+func WithSessionTags(tags map[string]string, transitiveKeys ...string) AssumeRoleOption {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return func(o *assumeRoleOptions) {
+		o.provider = append(o.provider, func(p *stscreds.AssumeRoleProvider) {
+			for _, key := range keys {
+				p.Tags = append(p.Tags, &sts.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+			}
+			p.TransitiveTagKeys = append(p.TransitiveTagKeys, aws.StringSlice(transitiveKeys)...)
+		})
+	}
+}
+
+// AssumeRole returns credentials of the role roleARN, e.g. to call an AppSync API of another account. Role sessions
+// are created lazily with STS AssumeRole, and renewed shortly before they expire, so the credentials can be given
+// once to a Client:
+//
+//	creds, err := iamsigned.AssumeRole("arn:aws:iam::123456789012:role/appsync-caller", iamsigned.WithExternalID(id))
+//	client := iamsigned.NewClient(endpoint, region, creds)
+func AssumeRole(roleARN string, opts ...AssumeRoleOption) (*credentials.Credentials, error) {
+	o := &assumeRoleOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	config := aws.Config{}
+	if o.source != nil {
+		config.Credentials = o.source
+	}
+	if o.region != "" {
+		config.Region = aws.String(o.region)
+		config.STSRegionalEndpoint = endpoints.RegionalSTSEndpoint
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{Config: config, SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, fmt.Errorf("could not create STS session: %w", err)
+	}
+
+	provider := append([]func(*stscreds.AssumeRoleProvider){func(p *stscreds.AssumeRoleProvider) {
+		// renew early enough for in-flight requests signed just before the expiry
+		p.ExpiryWindow = time.Minute
+	}}, o.provider...)
+	return stscreds.NewCredentials(sess, roleARN, provider...), nil
+}
@@ -0,0 +1,40 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// WithAmzTarget sets the X-Amz-Target header naming the operation of a JSON-RPC style AWS API (e.g.
+// "AWSEvents.PutEvents"). It's signed like any other header.
+func WithAmzTarget(target string) Option {
+	return WithHeader("X-Amz-Target", target)
+}
+
+// AWSJSON calls an operation of an API using the AWS JSON protocol, such as EventBridge, DynamoDB, SQS or CloudWatch
+// Logs, without the SDK service client. target is the X-Amz-Target of the operation (e.g. "AWSEvents.PutEvents" or
+// "DynamoDB_20120810.GetItem"), and payload its JSON input. An empty endpoint calls the regional endpoint of the
+// service, when its signing name is also its endpoint prefix.
+//
+// The payload is sent as application/x-amz-json-1.1: APIs on the 1.0 version of the protocol, like DynamoDB, need
+// WithContentType("application/x-amz-json-1.0"). Errors are parsed as AWS error envelopes (see WithAWSErrorEnvelope).
+func AWSJSON(ctx context.Context, payload []byte, service AWSService, endpoint, target, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	if endpoint == "" {
+		resolved, err := endpoints.DefaultResolver().EndpointFor(string(service), region)
+		if err != nil {
+			return nil, o.wrapError(err)
+		}
+		endpoint = resolved.URL
+	}
+	if o.contentType == "" {
+		o.contentType = "application/x-amz-json-1.1"
+	}
+	o.setHeader("X-Amz-Target", target)
+	o.parseErrorEnvelope = true
+	o.expectJSON = true
+	return deliverBytes(ctx, payload, service, endpoint, region, http.MethodPost, creds, o)
+}
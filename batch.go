@@ -0,0 +1,70 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// defaultConcurrency is the number of requests BatchAppSync runs at once unless WithConcurrency is used
+const defaultConcurrency = 8
+
+// BatchResult is the outcome of one request of a batch
+type BatchResult struct {
+	Data json.RawMessage
+	Err  error
+}
+
+// WithConcurrency sets how many requests of a batch run at once (8 by default)
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// BatchAppSync sends every request concurrently, within the WithConcurrency limit, and returns their results in the
+// same order. A failed request doesn't stop the others; once ctx is done, the requests not sent yet fail with its
+// error and the in-flight ones are canceled.
+//
+// WithResponseInfo can't be given to a batch, as its requests would fill the same info concurrently: every request
+// fails instead.
+func BatchAppSync(ctx context.Context, reqs []GraphQLRequest, endpoint, region string, creds *credentials.Credentials, opts ...Option) []BatchResult {
+	results := make([]BatchResult, len(reqs))
+	probe := newOptions(opts)
+	if probe.info != nil {
+		for i := range results {
+			results[i].Err = errors.New("WithResponseInfo is a per-call option, it can't be given to BatchAppSync")
+		}
+		return results
+	}
+	concurrency := probe.concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(reqs); j++ {
+				results[j].Err = ctx.Err()
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func(i int, req GraphQLRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i].Data, results[i].Err = AppSyncQuery(ctx, req, endpoint, region, creds, opts...)
+		}(i, req)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,24 @@
+package iamsigned
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBatchAppSyncResponseInfo(t *testing.T) {
+	server := appSyncServer(t, `{"data":{"a":1}}`)
+	reqs := []GraphQLRequest{{Query: "{ a }"}, {Query: "{ a }"}}
+
+	for i, result := range BatchAppSync(context.Background(), reqs, server.URL, "eu-west-1", testCreds) {
+		if result.Err != nil || string(result.Data) != `{"a":1}` {
+			t.Errorf("request %d: got %s, %v", i, result.Data, result.Err)
+		}
+	}
+
+	var info ResponseInfo
+	for i, result := range BatchAppSync(context.Background(), reqs, server.URL, "eu-west-1", testCreds, WithResponseInfo(&info)) {
+		if result.Err == nil {
+			t.Errorf("request %d: WithResponseInfo was accepted by BatchAppSync", i)
+		}
+	}
+}
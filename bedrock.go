@@ -0,0 +1,186 @@
+package iamsigned
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// maxEventStreamMessage bounds the size of a single event stream message
+const maxEventStreamMessage = 16 << 20
+
+// BedrockInvoke runs a Bedrock InvokeModel call on the regional bedrock-runtime endpoint, and returns the response of
+// the model. modelID is a model id, inference profile or ARN, and payload the request in the format of the model. The
+// response is asked for as application/json, unless an Accept header is given (WithHeader).
+func BedrockInvoke(ctx context.Context, payload []byte, modelID, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	if o.header.Get("Accept") == "" {
+		o.setHeader("Accept", "application/json")
+	}
+	return deliverBytes(ctx, payload, BedrockService, bedrockURL(region, modelID, "invoke"), region, http.MethodPost, creds, o)
+}
+
+// BedrockInvokeStream runs a Bedrock InvokeModelWithResponseStream call, and returns the stream of the chunks of the
+// response, as the model produces them. The caller must close it. The chunks are asked for as application/json,
+// unless an X-Amzn-Bedrock-Accept header is given (WithHeader).
+func BedrockInvokeStream(ctx context.Context, payload []byte, modelID, region string, creds *credentials.Credentials, opts ...Option) (*EventStream, error) {
+	o := newOptions(opts)
+	o.setHeader("Accept", "application/vnd.amazon.eventstream")
+	if o.header.Get("X-Amzn-Bedrock-Accept") == "" {
+		o.setHeader("X-Amzn-Bedrock-Accept", "application/json")
+	}
+	response, err := deliverStream(ctx, payload, BedrockService, bedrockURL(region, modelID, "invoke-with-response-stream"), region, http.MethodPost, creds, o)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{body: response.Body, reader: bufio.NewReader(response.Body)}, nil
+}
+
+// bedrockURL returns the URL of a model operation, the model id being escaped as it may be an ARN
+func bedrockURL(region, modelID, operation string) string {
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s/%s", region, escapePathSegment(modelID), operation)
+}
+
+// EventStream reads the chunks of a Bedrock response stream (application/vnd.amazon.eventstream)
+type EventStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// EventStreamMessage is a message of an event stream. Headers only holds the string headers, such as :event-type.
+type EventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// Recv returns the next chunk of the response, as produced by the model, and io.EOF once the stream ends. An
+// exception sent by Bedrock in the stream is returned as an error.
+func (s *EventStream) Recv() (json.RawMessage, error) {
+	for {
+		msg, err := ReadEventStreamMessage(s.reader)
+		if err != nil {
+			return nil, err
+		}
+		switch msg.Headers[":message-type"] {
+		case "exception", "error":
+			return nil, eventStreamError(msg)
+		}
+		if msg.Headers[":event-type"] != "chunk" {
+			continue
+		}
+		var chunk struct {
+			Bytes []byte `json:"bytes"`
+		}
+		if err := json.Unmarshal(msg.Payload, &chunk); err != nil {
+			return nil, fmt.Errorf("could not parse chunk: %w", err)
+		}
+		return chunk.Bytes, nil
+	}
+}
+
+// Close closes the response body
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// ReadEventStreamMessage reads a message in the AWS event stream framing, checking its checksums. It returns io.EOF
+// when r ends between messages.
+func ReadEventStreamMessage(r io.Reader) (EventStreamMessage, error) {
+	var msg EventStreamMessage
+	var prelude [12]byte
+	if _, err := io.ReadFull(r, prelude[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return msg, io.EOF
+		}
+		return msg, fmt.Errorf("could not read event stream message: %w", err)
+	}
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return msg, errors.New("could not read event stream message: prelude checksum mismatch")
+	}
+	if totalLength < 16+headersLength || totalLength > maxEventStreamMessage {
+		return msg, fmt.Errorf("could not read event stream message: invalid length %d", totalLength)
+	}
+
+	message := make([]byte, totalLength)
+	copy(message, prelude[:])
+	if _, err := io.ReadFull(r, message[12:]); err != nil {
+		return msg, fmt.Errorf("could not read event stream message: %w", err)
+	}
+	end := totalLength - 4
+	if crc32.ChecksumIEEE(message[:end]) != binary.BigEndian.Uint32(message[end:]) {
+		return msg, errors.New("could not read event stream message: message checksum mismatch")
+	}
+
+	headers, err := parseEventStreamHeaders(message[12 : 12+headersLength])
+	if err != nil {
+		return msg, err
+	}
+	msg.Headers = headers
+	msg.Payload = message[12+headersLength : end]
+	return msg, nil
+}
+
+// eventStreamValueSizes is the size of the fixed-length header value types, by type
+var eventStreamValueSizes = map[byte]int{0: 0, 1: 0, 2: 1, 3: 2, 4: 4, 5: 8, 8: 8, 9: 16}
+
+// parseEventStreamHeaders decodes the headers of a message, keeping the string ones
+func parseEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	invalid := errors.New("could not read event stream message: invalid headers")
+	for len(b) > 0 {
+		nameLength := int(b[0])
+		if len(b) < 1+nameLength+1 {
+			return nil, invalid
+		}
+		name := string(b[1 : 1+nameLength])
+		valueType := b[1+nameLength]
+		b = b[2+nameLength:]
+
+		if size, ok := eventStreamValueSizes[valueType]; ok {
+			if len(b) < size {
+				return nil, invalid
+			}
+			b = b[size:]
+			continue
+		}
+		// byte array (6) and string (7) values are prefixed with their length
+		if (valueType != 6 && valueType != 7) || len(b) < 2 {
+			return nil, invalid
+		}
+		valueLength := int(binary.BigEndian.Uint16(b))
+		if len(b) < 2+valueLength {
+			return nil, invalid
+		}
+		if valueType == 7 {
+			headers[name] = string(b[2 : 2+valueLength])
+		}
+		b = b[2+valueLength:]
+	}
+	return headers, nil
+}
+
+// eventStreamError converts an exception message
+func eventStreamError(msg EventStreamMessage) error {
+	var payload struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+	kind := msg.Headers[":exception-type"]
+	if kind == "" {
+		kind = msg.Headers[":error-code"]
+	}
+	if payload.Message == "" {
+		payload.Message = snippet(msg.Payload)
+	}
+	return fmt.Errorf("event stream %s: %s", kind, payload.Message)
+}
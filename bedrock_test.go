@@ -0,0 +1,49 @@
+package iamsigned
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc answers requests without a server, e.g. for calls to fixed AWS endpoints
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBedrockInvokeAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		accept string
+	}{
+		{"default", nil, "application/json"},
+		{"given", []Option{WithHeader("Accept", "text/plain")}, "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept string
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				accept = req.Header.Get("Accept")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+					Request:    req,
+				}, nil
+			})}
+			opts := append([]Option{WithHTTPClient(client)}, tt.opts...)
+			if _, err := BedrockInvoke(context.Background(), []byte(`{}`), "anthropic.model", "us-east-1", testCreds,
+				opts...); err != nil {
+				t.Fatalf("could not invoke: %v", err)
+			}
+			if accept != tt.accept {
+				t.Errorf("got Accept %q, want %q", accept, tt.accept)
+			}
+		})
+	}
+}
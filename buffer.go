@@ -0,0 +1,44 @@
+package iamsigned
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxBodySizeHint bounds the memory preallocated from a Content-Length header
+const maxBodySizeHint = 8 << 20
+
+// bufferedBody is a response body already read in memory. readAll hands its bytes over instead of copying them.
+type bufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newBufferedBody(data []byte) *bufferedBody {
+	return &bufferedBody{Reader: bytes.NewReader(data), data: data}
+}
+
+func (b *bufferedBody) Close() error {
+	return nil
+}
+
+// readAll reads body, without copying it when it was already buffered
+func readAll(body io.Reader) ([]byte, error) {
+	if buffered, ok := body.(*bufferedBody); ok && buffered.Len() == len(buffered.data) {
+		return buffered.data, nil
+	}
+	return io.ReadAll(body)
+}
+
+// bodySizeHint returns the expected size of the decoded body of response, or 0 when unknown
+func bodySizeHint(response *http.Response) int {
+	if response == nil || response.Uncompressed || response.ContentLength <= 0 || response.ContentLength > maxBodySizeHint {
+		return 0
+	}
+	if encoding := response.Header.Get("Content-Encoding"); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return 0
+	}
+	return int(response.ContentLength)
+}
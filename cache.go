@@ -0,0 +1,172 @@
+package iamsigned
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// defaultCacheEntries bounds the response cache unless WithCache is given a size
+const defaultCacheEntries = 1000
+
+// WithCache keeps successful buffered responses (AppSync, APIGateway, Deliver...) in memory for ttl, keyed by the
+// service, endpoint, region, query, method, headers, payload and credentials of the call, so identical calls are
+// answered without sending a request. AppSync responses holding GraphQL errors are not kept. At most maxEntries
+// responses are kept (1000 when <= 0), evicting the least recently used ones.
+//
+// Given to NewClient, the cache is shared by every call of the Client. Only cache read-only operations, and send
+// mutations with NoCache.
+func WithCache(ttl time.Duration, maxEntries int) Option {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+	cache := &responseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[[sha256.Size]byte]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// NoCache neither answers the call from the cache, nor stores its response
+func NoCache() Option {
+	return func(o *options) {
+		o.noCache = true
+	}
+}
+
+type responseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	entries    map[[sha256.Size]byte]*list.Element
+	// order lists the entries from the most to the least recently used
+	order *list.List
+	now   func() time.Time
+}
+
+type cacheEntry struct {
+	key     [sha256.Size]byte
+	data    []byte
+	expires time.Time
+}
+
+func (c *responseCache) get(key [sha256.Size]byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.data, true
+}
+
+func (c *responseCache) put(key [sha256.Size]byte, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &cacheEntry{key: key, data: data, expires: c.now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey hashes what identifies a call: the request as signed, and who signs it. It's false when the credentials
+// can't be resolved, for the call to fail as usual.
+func (o *options) cacheKey(service AWSService, endpoint, region, method string, creds *credentials.Credentials, payload []byte) ([sha256.Size]byte, bool) {
+	var key [sha256.Size]byte
+	identity, ok := o.credentialIdentity(creds)
+	if !ok {
+		return key, false
+	}
+	h := sha256.New()
+	write := func(parts ...string) {
+		for _, part := range parts {
+			h.Write([]byte(part))
+			h.Write([]byte{0})
+		}
+	}
+	write(string(service), endpoint, region, o.query.Encode(), method, o.requestContentType(), o.authorizationHeader,
+		identity)
+	names := make([]string, 0, len(o.header))
+	for name := range o.header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		write(name)
+		write(o.header[name]...)
+	}
+	h.Write(payload)
+	h.Sum(key[:0])
+	return key, true
+}
+
+// credentialIdentity identifies who signs the call: the access key of the credentials, or the custom signer
+func (o *options) credentialIdentity(creds *credentials.Credentials) (string, bool) {
+	if o.signer != nil {
+		return fmt.Sprintf("signer %T %p", o.signer, o.signer), true
+	}
+	creds, err := o.resolveCredentials(creds)
+	if err != nil {
+		return "", false
+	}
+	value, err := creds.Get()
+	if err != nil {
+		return "", false
+	}
+	return value.AccessKeyID, true
+}
+
+// cached answers from the cache when possible, and otherwise stores the response of deliver. AppSync responses holding
+// GraphQL errors are not stored.
+func (o *options) cached(service AWSService, endpoint, region, method string, creds *credentials.Credentials, payload []byte, deliver func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if o.cache == nil || o.noCache || !o.buffer || o.body != nil {
+		return deliver()
+	}
+	key, ok := o.cacheKey(service, endpoint, region, method, creds, payload)
+	if !ok {
+		return deliver()
+	}
+	// responses are copied in and out so callers can't alter the cached bytes
+	if data, ok := o.cache.get(key); ok {
+		return newBufferedBody(append([]byte(nil), data...)), nil
+	}
+	body, err := deliver()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if service != AppSyncService || CheckGraphQLResponse(bytes.NewReader(data)) == nil {
+		o.cache.put(key, append([]byte(nil), data...))
+	}
+	return newBufferedBody(data), nil
+}
@@ -0,0 +1,127 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// targetServer answers the X-Amz-Target of each request, and counts them
+func targetServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"target":"` + r.Header.Get("X-Amz-Target") + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCache(t *testing.T) {
+	otherCreds := credentials.NewStaticCredentials("AKIDOTHER", "secret", "")
+	tests := []struct {
+		name     string
+		first    string
+		second   string
+		creds    *credentials.Credentials
+		opts     []Option
+		requests int32
+	}{
+		{"identical calls hit", "A.One", "A.One", testCreds, nil, 1},
+		{"other targets miss", "A.One", "A.Two", testCreds, nil, 2},
+		{"other headers miss", "A.One", "A.One", testCreds, []Option{WithHeader("X-Tenant", "other")}, 2},
+		{"other content types miss", "A.One", "A.One", testCreds, []Option{WithContentType("application/x-amz-json-1.0")}, 2},
+		{"other credentials miss", "A.One", "A.One", otherCreds, nil, 2},
+		{"no cache", "A.One", "A.One", testCreds, []Option{NoCache()}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := targetServer(t, &requests)
+			cache := WithCache(time.Minute, 0)
+
+			first, err := AWSJSON(context.Background(), []byte(`{}`), "events", server.URL, tt.first, "eu-west-1", testCreds, cache)
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			second, err := AWSJSON(context.Background(), []byte(`{}`), "events", server.URL, tt.second, "eu-west-1", tt.creds,
+				append([]Option{cache}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("expected %d requests, got %d", tt.requests, got)
+			}
+			if want := `{"target":"` + tt.second + `"}`; string(second) != want {
+				t.Errorf("expected %s for the second call, got %s (first got %s)", want, second, first)
+			}
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	var requests atomic.Int32
+	server := targetServer(t, &requests)
+	cache := WithCache(time.Minute, 0)
+	now := time.Now()
+	newOptions([]Option{cache}).cache.now = func() time.Time { return now }
+
+	call := func() {
+		t.Helper()
+		if _, err := AWSJSON(context.Background(), []byte(`{}`), "events", server.URL, "A.One", "eu-west-1", testCreds, cache); err != nil {
+			t.Fatalf("could not call: %v", err)
+		}
+	}
+	call()
+	now = now.Add(59 * time.Second)
+	call()
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected the response to be cached within the ttl, got %d requests", got)
+	}
+	now = now.Add(2 * time.Second)
+	call()
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected the response to expire after the ttl, got %d requests", got)
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	var requests atomic.Int32
+	server := targetServer(t, &requests)
+	cache := WithCache(time.Minute, 2)
+
+	for _, target := range []string{"A.One", "A.Two", "A.One", "A.Three", "A.One", "A.Two"} {
+		if _, err := AWSJSON(context.Background(), []byte(`{}`), "events", server.URL, target, "eu-west-1", testCreds, cache); err != nil {
+			t.Fatalf("could not call %s: %v", target, err)
+		}
+	}
+	// A.Three evicts A.Two, the least recently used, while A.One stays cached
+	if got := requests.Load(); got != 4 {
+		t.Errorf("expected 4 requests, got %d", got)
+	}
+}
+
+func TestCacheSkipsGraphQLErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":null,"errors":[{"message":"boom"}]}`))
+	}))
+	defer server.Close()
+	cache := WithCache(time.Minute, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := AppSyncWithContext(context.Background(), []byte(`{"query":"{ a }"}`), server.URL, "eu-west-1", testCreds, cache); err == nil {
+			t.Fatal("expected the GraphQL errors to fail the call")
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected responses holding GraphQL errors not to be cached, got %d requests", got)
+	}
+}
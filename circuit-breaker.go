@@ -0,0 +1,92 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit breaker of the endpoint is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WithCircuitBreaker opens a circuit per endpoint after threshold consecutive failed attempts (transport errors or 5xx
+// responses): while it's open, calls to the endpoint fail fast with ErrCircuitOpen. Once cooldown has elapsed, a
+// single attempt is let through to probe the endpoint, closing the circuit if it succeeds, or keeping it open for
+// another cooldown otherwise. Given to NewClient, the circuits are shared by every call of the Client.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	breaker := &circuitBreaker{threshold: threshold, cooldown: cooldown, circuits: make(map[string]*circuit), now: time.Now}
+	return func(o *options) {
+		o.breaker = breaker
+	}
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mu        sync.Mutex
+	circuits  map[string]*circuit
+	now       func() time.Time
+}
+
+type circuit struct {
+	failures int
+	// openedAt is when the circuit opened, or when the last probe went through
+	openedAt time.Time
+}
+
+// allow tells whether an attempt to endpoint may be sent
+func (b *circuitBreaker) allow(endpoint string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[endpoint]
+	if !ok || c.failures < b.threshold {
+		return nil
+	}
+	now := b.now()
+	if now.Sub(c.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	// half-open: this attempt is the probe, the next one waits for another cooldown
+	c.openedAt = now
+	return nil
+}
+
+// record updates the circuit of endpoint with the outcome of an attempt
+func (b *circuitBreaker) record(endpoint string, response *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil && response.StatusCode < 500 {
+		delete(b.circuits, endpoint)
+		return
+	}
+	c, ok := b.circuits[endpoint]
+	if !ok {
+		c = &circuit{}
+		b.circuits[endpoint] = c
+	}
+	c.failures++
+	if c.failures == b.threshold {
+		c.openedAt = b.now()
+	}
+}
+
+// admit fails fast when the circuit of endpoint is open
+func (o *options) admit(endpoint string) error {
+	if o.breaker == nil {
+		return nil
+	}
+	return o.breaker.allow(endpoint)
+}
+
+// recordOutcome reports the outcome of an attempt to the circuit breaker, if any. Attempts canceled by the caller
+// don't count.
+func (o *options) recordOutcome(ctx context.Context, endpoint string, response *http.Response, err error) {
+	if o.breaker != nil && ctx.Err() == nil {
+		o.breaker.record(endpoint, response, err)
+	}
+}
@@ -0,0 +1,68 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const endpoint = "https://api.example.com"
+	failure := &http.Response{StatusCode: http.StatusServiceUnavailable}
+	success := &http.Response{StatusCode: http.StatusOK}
+	now := time.Now()
+	breaker := newOptions([]Option{WithCircuitBreaker(2, time.Minute)}).breaker
+	breaker.now = func() time.Time { return now }
+
+	steps := []struct {
+		name    string
+		elapsed time.Duration
+		outcome *http.Response
+		err     error
+		open    bool
+	}{
+		{"closed", 0, failure, nil, false},
+		{"below the threshold", 0, failure, nil, false},
+		{"open at the threshold", 0, nil, nil, true},
+		{"open within the cooldown", 59 * time.Second, nil, nil, true},
+		{"half-open after the cooldown", 2 * time.Second, failure, nil, false},
+		{"open again after a failed probe", 0, nil, nil, true},
+		{"half-open after another cooldown", time.Minute, success, nil, false},
+		{"closed after a successful probe", 0, nil, errors.New("connection reset"), false},
+		{"a transport error counts", 0, failure, nil, false},
+		{"open again", 0, nil, nil, true},
+	}
+	for _, step := range steps {
+		now = now.Add(step.elapsed)
+		err := breaker.allow(endpoint)
+		if open := errors.Is(err, ErrCircuitOpen); open != step.open {
+			t.Fatalf("%s: got %v, want open: %v", step.name, err, step.open)
+		}
+		if step.outcome != nil || step.err != nil {
+			breaker.record(endpoint, step.outcome, step.err)
+		}
+	}
+}
+
+func TestCircuitBreakerFailsFast(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "eu-west-1", testCreds, WithMaxAttempts(1), WithCircuitBreaker(1, time.Minute))
+
+	if _, err := client.APIGateway(context.Background(), nil, http.MethodGet); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want the 503 of the first call", err)
+	}
+	if _, err := client.APIGateway(context.Background(), nil, http.MethodGet); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("got %v, want ErrCircuitOpen", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request, got %d", requests)
+	}
+}
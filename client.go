@@ -0,0 +1,194 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// Client sends signed requests to a single endpoint. Its options apply to every call, before the per-call ones.
+//
+// A Client is safe for concurrent use, and should be reused rather than created for each request.
+type Client struct {
+	endpoint string
+	region   string
+	creds    *credentials.Credentials
+	opts     []Option
+	// httpClient is owned by the client, when no other was given
+	httpClient *http.Client
+}
+
+// clientIdleConns is the number of idle connections a Client keeps to its endpoint. http.DefaultTransport only keeps
+// 2 per host, so busy clients keep opening new TLS connections.
+const clientIdleConns = 100
+
+// NewClient creates a client for endpoint. Nil credentials fall back to the default credentials (see
+// SetDefaultCredentials) at call time.
+//
+// The client signs with a single signer, and unless WithHTTPClient is given sends requests through its own pooled
+// transport, keeping up to 100 idle connections to the endpoint, and configured by the transport options (e.g.
+// WithTLSConfig).
+func NewClient(endpoint, region string, creds *credentials.Credentials, opts ...Option) *Client {
+	c := &Client{endpoint: endpoint, region: region, creds: creds}
+	var signer *v4.Signer
+	if creds != nil {
+		signer = v4.NewSigner(creds)
+	}
+	probe := &options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(probe)
+		}
+	}
+	if probe.httpClient == nil && probe.pinnedClient == nil {
+		c.httpClient = &http.Client{Transport: probe.transport.newTransport()}
+	}
+	client, settings, clock := c.httpClient, probe.transport, &clockOffset{}
+	c.opts = append(c.opts, func(o *options) {
+		o.v1Signer = signer
+		o.clock = clock
+		o.defaultClient = client
+		o.defaultTransport = settings
+	})
+	c.opts = append(c.opts, opts...)
+	if probe.info != nil {
+		// concurrent calls would fill the same ResponseInfo
+		c.opts = append(c.opts, func(o *options) {
+			o.err = errors.New("WithResponseInfo is a per-call option, it can't be given to NewClient")
+		})
+	}
+	return c
+}
+
+// CloseIdleConnections closes the idle connections of the client transport, e.g. before the client is dropped
+func (c *Client) CloseIdleConnections() {
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+}
+
+// options merges the client and per-call options
+func (c *Client) options(opts []Option) []Option {
+	if len(opts) == 0 {
+		return c.opts
+	}
+	merged := make([]Option, 0, len(c.opts)+len(opts))
+	merged = append(merged, c.opts...)
+	return append(merged, opts...)
+}
+
+// Do signs and sends a request to any service hosted at the client endpoint, and returns the response body
+func (c *Client) Do(ctx context.Context, payload []byte, service AWSService, method string, opts ...Option) ([]byte, error) {
+	return deliverBytes(ctx, payload, service, c.endpoint, c.region, method, c.creds, newOptions(c.options(opts)))
+}
+
+// Deliver does the same as the package-level Deliver, against the client endpoint
+func (c *Client) Deliver(ctx context.Context, payload []byte, service AWSService, method string, opts ...Option) (io.ReadCloser, error) {
+	return Deliver(ctx, payload, service, c.endpoint, c.region, method, c.creds, c.options(opts)...)
+}
+
+// AppSync sends a GraphQL request, and returns its data once checked for GraphQL errors
+func (c *Client) AppSync(ctx context.Context, payload []byte, opts ...Option) (json.RawMessage, error) {
+	return AppSyncWithContext(ctx, payload, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// AppSyncQuery does the same as the package-level AppSyncQuery, against the client endpoint
+func (c *Client) AppSyncQuery(ctx context.Context, req GraphQLRequest, opts ...Option) (json.RawMessage, error) {
+	return AppSyncQuery(ctx, req, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// AppSyncResult does the same as the package-level AppSyncResult, against the client endpoint
+func (c *Client) AppSyncResult(ctx context.Context, req GraphQLRequest, opts ...Option) (GraphQLResult, error) {
+	return AppSyncResult(ctx, req, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// BatchAppSync does the same as the package-level BatchAppSync, against the client endpoint
+func (c *Client) BatchAppSync(ctx context.Context, reqs []GraphQLRequest, opts ...Option) []BatchResult {
+	return BatchAppSync(ctx, reqs, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// AppSyncBatched does the same as the package-level AppSyncBatched, against the client endpoint
+func (c *Client) AppSyncBatched(ctx context.Context, reqs []GraphQLRequest, opts ...Option) ([]BatchResult, error) {
+	return AppSyncBatched(ctx, reqs, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// AppSyncStream does the same as the package-level AppSyncStream, against the client endpoint
+func (c *Client) AppSyncStream(ctx context.Context, payload []byte, opts ...Option) (io.ReadCloser, error) {
+	return AppSyncStream(ctx, payload, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// AppSyncExec does the same as the package-level AppSyncExec, against the client endpoint
+func (c *Client) AppSyncExec(ctx context.Context, payload []byte, opts ...Option) error {
+	return AppSyncExec(ctx, payload, c.endpoint, c.region, c.creds, c.options(opts)...)
+}
+
+// APIGateway sends a request to API Gateway
+func (c *Client) APIGateway(ctx context.Context, payload []byte, method string, opts ...Option) ([]byte, error) {
+	return APIGatewayWithContext(ctx, payload, c.endpoint, c.region, method, c.creds, c.options(opts)...)
+}
+
+// APIGatewayMultipart sends a multipart/form-data request to API Gateway
+func (c *Client) APIGatewayMultipart(ctx context.Context, fields map[string]string, files []FormFile, method string, opts ...Option) ([]byte, error) {
+	return APIGatewayMultipart(ctx, fields, files, c.endpoint, c.region, method, c.creds, c.options(opts)...)
+}
+
+// PostToConnection sends payload to a client connected to the WebSocket API of the client endpoint
+func (c *Client) PostToConnection(ctx context.Context, payload []byte, connectionID string, opts ...Option) error {
+	return PostToConnection(ctx, payload, c.endpoint, connectionID, c.region, c.creds, c.options(opts)...)
+}
+
+// DeleteConnection disconnects a client from the WebSocket API of the client endpoint
+func (c *Client) DeleteConnection(ctx context.Context, connectionID string, opts ...Option) error {
+	return DeleteConnection(ctx, c.endpoint, connectionID, c.region, c.creds, c.options(opts)...)
+}
+
+// GetConnection returns the details of a client connected to the WebSocket API of the client endpoint
+func (c *Client) GetConnection(ctx context.Context, connectionID string, opts ...Option) (ConnectionInfo, error) {
+	return GetConnection(ctx, c.endpoint, connectionID, c.region, c.creds, c.options(opts)...)
+}
+
+// LambdaURL sends a request to the Lambda function URL of the client
+func (c *Client) LambdaURL(ctx context.Context, payload []byte, method string, opts ...Option) ([]byte, error) {
+	return LambdaURL(ctx, payload, c.endpoint, c.region, method, c.creds, c.options(opts)...)
+}
+
+// AppSyncNamed sends a GraphQL request to the endpoint the client resolver (see WithEndpointResolver) maps name to
+func (c *Client) AppSyncNamed(ctx context.Context, payload []byte, name string, opts ...Option) (json.RawMessage, error) {
+	return AppSyncNamed(ctx, payload, name, c.creds, c.options(opts)...)
+}
+
+// APIGatewayNamed sends a request to the endpoint the client resolver (see WithEndpointResolver) maps name to
+func (c *Client) APIGatewayNamed(ctx context.Context, payload []byte, name, method string, opts ...Option) ([]byte, error) {
+	return APIGatewayNamed(ctx, payload, name, method, c.creds, c.options(opts)...)
+}
+
+// CallerIdentity returns the identity the client credentials sign as, using STS in the client region
+func (c *Client) CallerIdentity(ctx context.Context, opts ...Option) (account, arn, userID string, err error) {
+	return CallerIdentity(ctx, c.region, c.creds, c.options(opts)...)
+}
+
+// SendPresigned sends an already signed request with the client HTTP settings
+func (c *Client) SendPresigned(ctx context.Context, req *http.Request, opts ...Option) (*http.Response, error) {
+	return SendPresigned(ctx, req, c.options(opts)...)
+}
+
+// Presign does the same as the package-level Presign, against the client endpoint
+func (c *Client) Presign(payload []byte, service AWSService, method string, expires time.Duration, opts ...Option) (string, http.Header, error) {
+	return Presign(payload, service, c.endpoint, c.region, method, expires, c.creds, c.options(opts)...)
+}
+
+// Warmup opens a connection to the client endpoint ahead of the first request
+func (c *Client) Warmup(ctx context.Context, opts ...Option) error {
+	return Warmup(ctx, c.endpoint, c.options(opts)...)
+}
+
+// ToCurl formats a signed request to the client endpoint as a curl command
+func (c *Client) ToCurl(payload []byte, service AWSService, method string, opts ...Option) (string, error) {
+	return ToCurl(payload, service, c.endpoint, c.region, method, c.creds, c.options(opts)...)
+}
@@ -0,0 +1,46 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newBenchmarkServer(b *testing.B) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"getPost":{"id":"1"}}}`))
+	}))
+	b.Cleanup(server.Close)
+	return server
+}
+
+// BenchmarkClientAppSync reuses the signer and the pooled transport of a Client for every request
+func BenchmarkClientAppSync(b *testing.B) {
+	server := newBenchmarkServer(b)
+	client := NewClient(server.URL, "eu-west-1", testCreds)
+	payload := []byte(`{"query":"query { getPost(id: \"1\") { id } }"}`)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.AppSync(ctx, payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAppSyncWithContext builds a signer for every request, and sends it through http.DefaultClient
+func BenchmarkAppSyncWithContext(b *testing.B) {
+	server := newBenchmarkServer(b)
+	payload := []byte(`{"query":"query { getPost(id: \"1\") { id } }"}`)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := AppSyncWithContext(ctx, payload, server.URL, "eu-west-1", testCreds); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
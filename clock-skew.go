@@ -0,0 +1,97 @@
+package iamsigned
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// maxClockSkew is how far the signing clock may drift from the server clock before requests are signed with the
+// server time instead. AWS rejects signatures more than 5 minutes off.
+const maxClockSkew = 4 * time.Minute
+
+// clockOffset is the difference between the server clock and the local clock, in nanoseconds, as last measured on a
+// rejected request. Each Client measures its own, calls made without a Client share one per host.
+type clockOffset struct {
+	nanos atomic.Int64
+}
+
+// hostClocks holds the offsets of the hosts called without a Client, bounded for processes calling many of them: an
+// evicted host is measured again on its next rejected request
+var hostClocks = newLRUMap[string, *clockOffset](1000)
+
+// skewErrors are found in the bodies of the responses rejecting a signature because of the clock
+var skewErrors = [][]byte{
+	[]byte("RequestTimeTooSkewed"),
+	[]byte("InvalidSignatureException"),
+	[]byte("Signature expired"),
+}
+
+// hostClock returns the offset shared by the calls to the host of endpoint
+func hostClock(endpoint string) *clockOffset {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil {
+		host = u.Host
+	}
+	return hostClocks.get(host, func() *clockOffset { return &clockOffset{} })
+}
+
+func (c *clockOffset) offset() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return time.Duration(c.nanos.Load())
+}
+
+// NoClockSkewCorrection disables the clock skew correction. By default, when a request is rejected for its signing
+// time ("Signature expired", RequestTimeTooSkewed or InvalidSignatureException errors) and the Date of the response is
+// more than 4 minutes away from the local clock, the requests of the Client (or to the host, without a Client) are
+// signed with the server time from then on, and the rejected request is sent once more.
+func NoClockSkewCorrection() Option {
+	return func(o *options) {
+		o.noSkewCorrection = true
+	}
+}
+
+// correctClockSkew measures the clock skew from a rejected response, and tells whether it called for a correction
+func (o *options) correctClockSkew(response *http.Response) bool {
+	if o.noSkewCorrection || o.clock == nil || !o.signingTime.IsZero() || !rejectedSignature(response.StatusCode) ||
+		o.successful(response.StatusCode) {
+		return false
+	}
+	date, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return false
+	}
+	now := time.Now()
+	skew := date.Sub(now.Add(o.clock.offset()))
+	if skew > -maxClockSkew && skew < maxClockSkew || !rejectedForSkew(response) {
+		return false
+	}
+	o.clock.nanos.Store(int64(date.Sub(now)))
+	return true
+}
+
+// rejectedForSkew tells whether the error body of response is about the signing time. The part of the body read is
+// put back, for the response to be handled as usual otherwise.
+func rejectedForSkew(response *http.Response) bool {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+	response.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), response.Body), response.Body}
+	for _, marker := range skewErrors {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectedSignature tells whether a status code may be a rejected signature
+func rejectedSignature(code int) bool {
+	return code == http.StatusBadRequest || code == http.StatusUnauthorized || code == http.StatusForbidden
+}
@@ -0,0 +1,61 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClockSkewCorrection(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		attempts int
+		offset   bool
+	}{
+		{"signature expired", `{"message":"Signature expired: 20240101T000000Z is now earlier than ..."}`, 2, true},
+		{"too skewed", `<Error><Code>RequestTimeTooSkewed</Code></Error>`, 2, true},
+		{"invalid signature", `{"__type":"InvalidSignatureException"}`, 2, true},
+		{"access denied", `{"message":"User is not authorized to access this resource"}`, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverTime := time.Now().Add(time.Hour).UTC()
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+				signed, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+				if err != nil || serverTime.Sub(signed).Abs() > maxClockSkew {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(tt.body))
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "eu-west-1", testCreds)
+			_, err := client.APIGateway(context.Background(), nil, http.MethodGet)
+			if tt.offset && err != nil {
+				t.Errorf("could not call: %v", err)
+			}
+			if !tt.offset && err == nil {
+				t.Error("the rejected call succeeded")
+			}
+			if attempts != tt.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, tt.attempts)
+			}
+			offset := newOptions(client.options(nil)).clock.offset()
+			if corrected := offset != 0; corrected != tt.offset {
+				t.Errorf("got offset %v, want one: %v", offset, tt.offset)
+			}
+			other := NewClient(server.URL, "eu-west-1", testCreds)
+			if newOptions(other.options(nil)).clock.offset() != 0 {
+				t.Error("the offset leaked to another client")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package iamsigned
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultDecompressLimit bounds the size of decompressed response bodies unless WithResponseDecompressLimit is used
+const defaultDecompressLimit int64 = 64 << 20
+
+// ErrDecompressedTooLarge is returned while reading a compressed response that expands past the decompression limit
+var ErrDecompressedTooLarge = errors.New("decompressed response body exceeds the configured limit")
+
+// WithResponseDecompressLimit bounds how many bytes a compressed response may expand to (64MiB by default), to guard
+// against decompression bombs. Reading past the limit fails with ErrDecompressedTooLarge. A limit <= 0 disables the
+// check.
+func WithResponseDecompressLimit(limit int64) Option {
+	return func(o *options) {
+		o.decompressLimit = &limit
+	}
+}
+
+// WithGzipResponse asks for a gzip-compressed response by signing and sending Accept-Encoding: gzip. The response is
+// decompressed before it's returned or parsed (AppSync GraphQL responses included), within the decompression limit.
+func WithGzipResponse() Option {
+	return func(o *options) {
+		o.setHeader("Accept-Encoding", "gzip")
+	}
+}
+
+// WithGzipRequest gzip-compresses the request payload and sends it with Content-Encoding: gzip. The payload is hashed
+// and signed once compressed, as it's sent. The backend must accept compressed bodies (e.g. API Gateway REST APIs
+// with a minimum compression size set). Streamed bodies (DeliverReader) are sent as is.
+func WithGzipRequest() Option {
+	return func(o *options) {
+		o.gzipRequest = true
+	}
+}
+
+// compressPayload gzips the payload when requested
+func (o *options) compressPayload(payload []byte) ([]byte, error) {
+	if !o.gzipRequest || o.body != nil {
+		return payload, nil
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(payload); err != nil {
+		return nil, fmt.Errorf("could not compress payload: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("could not compress payload: %w", err)
+	}
+	o.setHeader("Content-Encoding", "gzip")
+	return compressed.Bytes(), nil
+}
+
+func (o *options) maxDecompressedBytes() int64 {
+	if o.decompressLimit == nil {
+		return defaultDecompressLimit
+	}
+	return *o.decompressLimit
+}
+
+// decodeBody returns the response body, decompressing it if needed
+func (o *options) decodeBody(response *http.Response) (io.ReadCloser, error) {
+	limit := o.maxDecompressedBytes()
+	body := response.Body
+
+	switch {
+	case strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip"):
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			body.Close()
+			return nil, fmt.Errorf("could not decompress response: %w", err)
+		}
+		return newLimitedBody(gz, limit, func() error {
+			gz.Close()
+			return body.Close()
+		}), nil
+	case response.Uncompressed:
+		// net/http already decompressed the body, but did not bound its size
+		return newLimitedBody(body, limit, body.Close), nil
+	default:
+		return body, nil
+	}
+}
+
+// limitedBody fails with ErrDecompressedTooLarge once more than limit bytes are read
+type limitedBody struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+	close  func() error
+}
+
+func newLimitedBody(r io.Reader, limit int64, close func() error) io.ReadCloser {
+	if limit <= 0 {
+		return &limitedBody{reader: r, close: close}
+	}
+	return &limitedBody{reader: io.LimitReader(r, limit+1), limit: limit, close: close}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+	if b.limit > 0 && b.read > b.limit {
+		return n - int(b.read-b.limit), ErrDecompressedTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.close()
+}
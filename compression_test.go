@@ -0,0 +1,105 @@
+package iamsigned
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipped(t testing.TB, data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	if err := gz.Close(); err != nil {
+		t.Fatalf("could not compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// gzipServer answers every request with body, gzip-compressed
+func gzipServer(t *testing.T, body []byte) *httptest.Server {
+	compressed := gzipped(t, body)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResponseDecompressLimit(t *testing.T) {
+	// a small compressed body expanding to 1MiB
+	body := append(append([]byte(`{"padding":"`), bytes.Repeat([]byte("0"), 1<<20)...), `"}`...)
+	server := gzipServer(t, body)
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		tooBig bool
+	}{
+		{"default limit", nil, false},
+		{"below the limit", []Option{WithResponseDecompressLimit(2 << 20)}, false},
+		{"above the limit", []Option{WithResponseDecompressLimit(1 << 10)}, true},
+		{"no limit", []Option{WithResponseDecompressLimit(0)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet,
+				testCreds, tt.opts...)
+			if tooBig := errors.Is(err, ErrDecompressedTooLarge); tooBig != tt.tooBig {
+				t.Fatalf("got error %v, want ErrDecompressedTooLarge: %v", err, tt.tooBig)
+			}
+			if !tt.tooBig && !bytes.Equal(data, body) {
+				t.Errorf("got %d bytes, want the %d decompressed ones", len(data), len(body))
+			}
+		})
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	body := []byte(`{"data":{"listPosts":{"items":[]}}}`)
+	compressed := gzipped(t, body)
+	var acceptEncoding, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding, authorization = r.Header.Get("Accept-Encoding"), r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		if acceptEncoding == "gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(compressed)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		signed bool
+	}{
+		// net/http asks for gzip itself, without signing it
+		{"default", nil, false},
+		{"gzip", []Option{WithGzipResponse()}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := AppSyncWithContext(context.Background(), []byte(`{"query":"{ listPosts { items { id } } }"}`),
+				server.URL, "eu-west-1", testCreds, tt.opts...)
+			if err != nil || string(data) != `{"listPosts":{"items":[]}}` {
+				t.Fatalf("got %s (%v), want the decompressed data", data, err)
+			}
+			if acceptEncoding != "gzip" {
+				t.Errorf("got Accept-Encoding %q, want gzip", acceptEncoding)
+			}
+			if signed := strings.Contains(authorization, "accept-encoding"); signed != tt.signed {
+				t.Errorf("got authorization %q, want accept-encoding signed: %v", authorization, tt.signed)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package iamsigned
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNotModified is returned when a conditional request gets a 304 Not Modified response. It is not a failure: the
+// copy the caller already holds is still valid.
+var ErrNotModified = errors.New("response not modified")
+
+// WithIfNoneMatch makes the request conditional on the resource's ETag differing from etag. The header is signed
+// with the rest of the request.
+func WithIfNoneMatch(etag string) Option {
+	return func(o *options) {
+		o.setHeader("If-None-Match", etag)
+	}
+}
+
+// WithIfModifiedSince makes the request conditional on the resource being modified after t. The header is signed
+// with the rest of the request.
+func WithIfModifiedSince(t time.Time) Option {
+	return func(o *options) {
+		o.setHeader("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+	}
+}
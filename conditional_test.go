@@ -0,0 +1,63 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	const etag = `"v2"`
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// conditional headers must be part of the signature, or a proxy could add them
+		for _, name := range []string{"If-None-Match", "If-Modified-Since"} {
+			if r.Header.Get(name) != "" && !strings.Contains(r.Header.Get("Authorization"), strings.ToLower(name)) {
+				http.Error(w, name+" is not signed", http.StatusBadRequest)
+				return
+			}
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"version":2}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		notModified bool
+	}{
+		{"unconditional", nil, false},
+		{"same etag", []Option{WithIfNoneMatch(etag)}, true},
+		{"stale etag", []Option{WithIfNoneMatch(`"v1"`)}, false},
+		{"not modified since", []Option{WithIfModifiedSince(modified.Add(time.Hour))}, true},
+		{"modified since", []Option{WithIfModifiedSince(modified.Add(-time.Hour))}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet,
+				testCreds, tt.opts...)
+			if tt.notModified {
+				if !errors.Is(err, ErrNotModified) {
+					t.Errorf("got %s (%v), want ErrNotModified", data, err)
+				}
+				return
+			}
+			if err != nil || string(data) != `{"version":2}` {
+				t.Errorf("got %s (%v), want the resource", data, err)
+			}
+		})
+	}
+}
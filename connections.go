@@ -0,0 +1,56 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// ConnectionInfo describes a client connected to an API Gateway WebSocket API
+type ConnectionInfo struct {
+	ConnectedAt  time.Time `json:"connectedAt"`
+	LastActiveAt time.Time `json:"lastActiveAt"`
+	Identity     struct {
+		SourceIP  string `json:"sourceIp"`
+		UserAgent string `json:"userAgent"`
+	} `json:"identity"`
+}
+
+// PostToConnection sends payload to a client connected to an API Gateway WebSocket API, through the @connections
+// management API. endpoint is the HTTPS URL of the API stage (e.g. https://<api-id>.execute-api.<region>.amazonaws.com/
+// <stage>), and connectionID the id API Gateway gave the connection. Once the client is gone, it fails with an
+// *HTTPError of status 410 (GoneException).
+func PostToConnection(ctx context.Context, payload []byte, endpoint, connectionID, region string, creds *credentials.Credentials, opts ...Option) error {
+	_, err := APIGatewayWithContext(ctx, payload, connectionURL(endpoint, connectionID), region, http.MethodPost, creds, opts...)
+	return err
+}
+
+// DeleteConnection disconnects a client from an API Gateway WebSocket API, see PostToConnection
+func DeleteConnection(ctx context.Context, endpoint, connectionID, region string, creds *credentials.Credentials, opts ...Option) error {
+	_, err := APIGatewayWithContext(ctx, nil, connectionURL(endpoint, connectionID), region, http.MethodDelete, creds, opts...)
+	return err
+}
+
+// GetConnection returns the details of a client connected to an API Gateway WebSocket API, see PostToConnection
+func GetConnection(ctx context.Context, endpoint, connectionID, region string, creds *credentials.Credentials, opts ...Option) (ConnectionInfo, error) {
+	var info ConnectionInfo
+	data, err := APIGatewayWithContext(ctx, nil, connectionURL(endpoint, connectionID), region, http.MethodGet, creds, opts...)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, newOptions(opts).wrapError(fmt.Errorf("could not parse connection: %w", err))
+	}
+	return info, nil
+}
+
+// connectionURL returns the @connections URL of a connection. Connection ids usually end with "=", which is escaped
+// as the AWS SDKs do, so the signature matches the path API Gateway verifies.
+func connectionURL(endpoint, connectionID string) string {
+	return strings.TrimSuffix(endpoint, "/") + "/@connections/" + escapePathSegment(connectionID)
+}
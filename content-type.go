@@ -0,0 +1,73 @@
+package iamsigned
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// snippetLength bounds how much of an unexpected body is quoted in error messages
+const snippetLength = 256
+
+// ErrMissingContentType is returned by strict callers (see WithRequireContentType) when a response has no
+// Content-Type header
+var ErrMissingContentType = errors.New("response has no Content-Type header")
+
+// WithContentType sets the Content-Type of the request, instead of application/json, e.g. application/octet-stream or
+// application/x-protobuf for an API Gateway route taking binary bodies. The payload is sent and hashed byte for byte,
+// whatever its type.
+func WithContentType(contentType string) Option {
+	return func(o *options) {
+		o.contentType = contentType
+	}
+}
+
+// WithRequireContentType rejects successful responses that have no Content-Type header with ErrMissingContentType.
+//
+// By default such responses are accepted: AppSync calls still attempt to parse the body as JSON, and raw API Gateway
+// calls return the body as is.
+func WithRequireContentType() Option {
+	return func(o *options) {
+		o.requireContentType = true
+	}
+}
+
+// checkContentType validates the Content-Type of a successful response. When the caller expects JSON, a response
+// explicitly declaring another media type (e.g. an HTML error page from a proxy) is rejected with a snippet of its
+// body.
+func (o *options) checkContentType(header http.Header, body io.Reader) error {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		if o.requireContentType {
+			return ErrMissingContentType
+		}
+		return nil
+	}
+	if !o.expectJSON || isJSONMediaType(contentType) {
+		return nil
+	}
+
+	head := make([]byte, snippetLength)
+	n, _ := io.ReadFull(body, head)
+	return fmt.Errorf("expected a JSON response but got '%s': '%s'", contentType, snippet(head[:n]))
+}
+
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		strings.HasPrefix(mediaType, "application/x-amz-json-")
+}
+
+// snippet returns the beginning of body, for error messages
+func snippet(body []byte) string {
+	if len(body) <= snippetLength {
+		return string(body)
+	}
+	return string(body[:snippetLength]) + "..."
+}
@@ -0,0 +1,50 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []Option
+		err         error
+		failed      bool
+	}{
+		{"json", "application/json", `{"data":{}}`, nil, nil, false},
+		{"json with charset", "application/json; charset=utf-8", `{"data":{}}`, nil, nil, false},
+		{"json suffix", "application/graphql-response+json", `{"data":{}}`, nil, nil, false},
+		{"missing", "", `{"data":{}}`, nil, nil, false},
+		{"missing but required", "", `{"data":{}}`, []Option{WithRequireContentType()}, ErrMissingContentType, true},
+		{"html error page", "text/html", `<html>Bad gateway</html>`, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType == "" {
+					// keep net/http from sniffing one
+					w.Header()["Content-Type"] = nil
+				} else {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := AppSyncWithContext(context.Background(), []byte(`{"query":"{ ping }"}`), server.URL, "eu-west-1",
+				testCreds, tt.opts...)
+			if failed := err != nil; failed != tt.failed {
+				t.Fatalf("got error %v, want a failure: %v", err, tt.failed)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrNilCredentials is returned when a request is sent without credentials, no default credentials were set, and the
+// default credential chain could not be loaded or holds no credentials
+var ErrNilCredentials = errors.New("nil credentials: pass credentials or call SetDefaultCredentials")
+
+var (
+	defaultCredentialsMu sync.RWMutex
+	defaultCredentials   *credentials.Credentials
+
+	// refreshes deduplicates concurrent refreshes of the same expired credentials
+	refreshes singleflight.Group
+
+	// chains caches the default credential chain of each profile
+	chainsMu sync.Mutex
+	chains   = make(map[string]*credentials.Credentials)
+)
+
+// SetDefaultCredentials sets the credentials used by every helper called with nil credentials, much like
+// http.DefaultClient. Credentials passed to a call always take precedence over the default. It is safe to call
+// concurrently, but is meant to be called once at startup.
+func SetDefaultCredentials(creds *credentials.Credentials) {
+	defaultCredentialsMu.Lock()
+	defer defaultCredentialsMu.Unlock()
+	defaultCredentials = creds
+}
+
+// DefaultCredentials returns the credentials set by SetDefaultCredentials, if any
+func DefaultCredentials() *credentials.Credentials {
+	defaultCredentialsMu.RLock()
+	defer defaultCredentialsMu.RUnlock()
+	return defaultCredentials
+}
+
+// WithProfile resolves credentials from the given shared config profile when a call is made with nil credentials,
+// instead of the default credentials. See ChainCredentials.
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// ChainCredentials returns the credentials of the default AWS credential chain, as the SDK resolves them: environment
+// variables, the shared config and credentials files (including SSO and assumed roles), then the ECS task role or the
+// EC2 instance role. An empty profile means AWS_PROFILE, or "default". The credentials are loaded once per profile,
+// and refresh themselves.
+//
+// Calls made with nil credentials fall back to it when no default credentials were set.
+func ChainCredentials(profile string) (*credentials.Credentials, error) {
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	if creds, ok := chains[profile]; ok {
+		return creds, nil
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not load the default credential chain: %w", err)
+	}
+	chains[profile] = sess.Config.Credentials
+	return sess.Config.Credentials, nil
+}
+
+// resolveCredentials falls back to the profile, the default credentials, then the default credential chain when
+// creds is nil
+func (o *options) resolveCredentials(creds *credentials.Credentials) (*credentials.Credentials, error) {
+	if creds != nil {
+		return creds, nil
+	}
+	if o.profile == "" {
+		if creds = DefaultCredentials(); creds != nil {
+			return creds, nil
+		}
+	}
+	creds, err := ChainCredentials(o.profile)
+	if err == nil {
+		// loading the chain succeeds even when no provider has credentials: only retrieving them tells
+		_, err = creds.Get()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNilCredentials, err)
+	}
+	return creds, nil
+}
+
+// refreshCredentials makes sure creds are valid before signing. When they expired, concurrent callers share a single
+// refresh instead of hammering the credentials provider, each one waiting for it within its own context.
+func refreshCredentials(ctx context.Context, creds *credentials.Credentials) error {
+	if !creds.IsExpired() {
+		return nil
+	}
+
+	results := refreshes.DoChan(fmt.Sprintf("%p", creds), func() (interface{}, error) {
+		return creds.GetWithContext(context.WithoutCancel(ctx))
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return &signingError{err: fmt.Errorf("could not retrieve credentials: %w", result.Err)}
+		}
+		return nil
+	}
+}
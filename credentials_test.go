@@ -0,0 +1,156 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// withoutAmbientCredentials empties every source of the default credential chain
+func withoutAmbientCredentials(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	for key, value := range map[string]string{
+		"AWS_ACCESS_KEY_ID":                      "",
+		"AWS_SECRET_ACCESS_KEY":                  "",
+		"AWS_SESSION_TOKEN":                      "",
+		"AWS_PROFILE":                            "",
+		"AWS_SHARED_CREDENTIALS_FILE":            missing,
+		"AWS_CONFIG_FILE":                        missing,
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI":     "",
+		"AWS_WEB_IDENTITY_TOKEN_FILE":            "",
+		"AWS_EC2_METADATA_DISABLED":              "true",
+	} {
+		t.Setenv(key, value)
+	}
+	chainsMu.Lock()
+	delete(chains, "")
+	chainsMu.Unlock()
+	t.Cleanup(func() {
+		chainsMu.Lock()
+		delete(chains, "")
+		chainsMu.Unlock()
+	})
+}
+
+func TestNilCredentials(t *testing.T) {
+	withoutAmbientCredentials(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"AppSyncWithContext", func() error {
+			_, err := AppSyncWithContext(ctx, []byte(`{}`), server.URL, "eu-west-1", nil)
+			return err
+		}},
+		{"APIGatewayWithContext", func() error {
+			_, err := APIGatewayWithContext(ctx, nil, server.URL, "eu-west-1", http.MethodGet, nil)
+			return err
+		}},
+		{"Deliver", func() error {
+			_, err := Deliver(ctx, nil, APIGatewayService, server.URL, "eu-west-1", http.MethodGet, nil)
+			return err
+		}},
+		{"Client", func() error {
+			_, err := NewClient(server.URL, "eu-west-1", nil).APIGateway(ctx, nil, http.MethodGet)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrNilCredentials) {
+				t.Errorf("got error %v, want ErrNilCredentials", err)
+			}
+		})
+	}
+	if requests != 0 {
+		t.Errorf("%d requests reached the server", requests)
+	}
+}
+
+// slowProvider takes delay to retrieve credentials, counting retrievals
+type slowProvider struct {
+	delay     time.Duration
+	err       error
+	retrieved atomic.Int32
+}
+
+func (p *slowProvider) Retrieve() (credentials.Value, error) {
+	p.retrieved.Add(1)
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return credentials.Value{}, p.err
+	}
+	return credentials.Value{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+}
+
+func (p *slowProvider) IsExpired() bool {
+	return p.retrieved.Load() == 0
+}
+
+func TestConcurrentCredentialRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		err        error
+		timeout    time.Duration
+		want       error
+		retrievals int32
+		// queued tells whether the callers waiting on the retrieval may retrieve once more
+		queued bool
+	}{
+		{"shared refresh", nil, time.Second, nil, 1, false},
+		// callers already waiting on the lock the SDK holds while retrieving see the credentials still expired once
+		// the retrieval failed, and share a second one
+		{"failing provider", errors.New("no IMDS"), time.Second, ErrSigning, 1, true},
+		{"caller gives up", nil, 10 * time.Millisecond, context.DeadlineExceeded, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &slowProvider{delay: 100 * time.Millisecond, err: tt.err}
+			creds := credentials.NewCredentials(provider)
+			var wg sync.WaitGroup
+			errs := make(chan error, 10)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+					defer cancel()
+					_, err := APIGatewayWithContext(ctx, nil, server.URL, "eu-west-1", http.MethodGet, creds)
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+					t.Errorf("got error %v, want %v", err, tt.want)
+				}
+			}
+			retrieved := provider.retrieved.Load()
+			if retrieved != tt.retrievals && !(tt.queued && retrieved == tt.retrievals+1) {
+				t.Errorf("credentials were retrieved %d times, want %d", retrieved, tt.retrievals)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+const redacted = "REDACTED"
+
+var signaturePattern = regexp.MustCompile(`Signature=[0-9a-f]+`)
+
+// WithRedaction hides secrets (the signature and session token) in debugging output such as ToCurl
+func WithRedaction() Option {
+	return func(o *options) {
+		o.redact = true
+	}
+}
+
+// ToCurl signs a request the same way Deliver would, and formats it as a copy-pasteable curl command, to reproduce
+// it outside the application. Use WithRedaction to hide the signature and session token.
+//
+// Warning: the signature is bound to the signing time, and AWS rejects it after a few minutes, so the command must
+// be run right away. WithGzipRequest is not supported, as curl can't send the exact compressed body that was signed.
+func ToCurl(payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if o.gzipRequest {
+		return "", o.wrapError(errors.New("ToCurl can't reproduce compressed requests (WithGzipRequest)"))
+	}
+	endpoint, region, creds, err := o.prepare(endpoint, region, creds)
+	if err != nil {
+		return "", o.wrapError(err)
+	}
+	req, err := newSignedRequest(context.Background(), payload, service, endpoint, region, method, creds, o)
+	if err != nil {
+		return "", o.wrapError(err)
+	}
+
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{"curl", "-X", req.Method, shellQuote(req.URL.String())}
+	for _, key := range keys {
+		for _, value := range req.Header[key] {
+			parts = append(parts, "-H", shellQuote(key+": "+o.redactHeader(key, value)))
+		}
+	}
+	if len(payload) > 0 {
+		parts = append(parts, "--data-binary", shellQuote(string(payload)))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// redactHeader hides the secret part of a header value, when redaction is enabled
+func (o *options) redactHeader(key, value string) string {
+	if !o.redact {
+		return value
+	}
+	switch http.CanonicalHeaderKey(key) {
+	case "X-Amz-Security-Token":
+		return redacted
+	case authorizationHeader, http.CanonicalHeaderKey(o.authorizationHeader):
+		return signaturePattern.ReplaceAllString(value, "Signature="+redacted)
+	}
+	return value
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+}
@@ -0,0 +1,30 @@
+package iamsigned
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestToCurl(t *testing.T) {
+	command, err := ToCurl([]byte(`{"it's":1}`), APIGatewayService, "https://api.example.com/items", "eu-west-1",
+		http.MethodPost, testCreds, WithRedaction())
+	if err != nil {
+		t.Fatalf("could not format: %v", err)
+	}
+	for _, want := range []string{
+		"curl -X POST 'https://api.example.com/items'",
+		"-H 'Content-Type: application/json'",
+		"Signature=REDACTED'",
+		`--data-binary '{"it'\''s":1}'`,
+	} {
+		if !strings.Contains(command, want) {
+			t.Errorf("expected %s in %s", want, command)
+		}
+	}
+
+	if _, err := ToCurl([]byte(`{}`), APIGatewayService, "https://api.example.com/items", "eu-west-1", http.MethodPost,
+		testCreds, WithGzipRequest()); err == nil {
+		t.Error("ToCurl formatted a compressed request")
+	}
+}
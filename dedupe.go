@@ -0,0 +1,49 @@
+package iamsigned
+
+import (
+	"encoding/hex"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"golang.org/x/sync/singleflight"
+)
+
+// WithDeduplication collapses identical concurrent buffered calls (same service, endpoint, region, query, method,
+// headers, payload and credentials) into a single signed request, whose response is shared by all the callers. Given
+// to NewClient, it applies across every call of the Client.
+//
+// The shared request runs with the context and options of the first caller: if it's canceled, the calls waiting on it
+// fail as well.
+func WithDeduplication() Option {
+	group := &singleflight.Group{}
+	return func(o *options) {
+		o.inflight = group
+	}
+}
+
+// deduplicated shares the response of deliver between identical concurrent calls
+func (o *options) deduplicated(service AWSService, endpoint, region, method string, creds *credentials.Credentials, payload []byte, deliver func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if o.inflight == nil || !o.buffer || o.body != nil {
+		return deliver()
+	}
+	key, ok := o.cacheKey(service, endpoint, region, method, creds, payload)
+	if !ok {
+		return deliver()
+	}
+	result, err, shared := o.inflight.Do(hex.EncodeToString(key[:]), func() (interface{}, error) {
+		body, err := deliver()
+		if err != nil {
+			return nil, err
+		}
+		return readAll(body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := result.([]byte)
+	if shared {
+		// every caller gets its own copy
+		data = append([]byte(nil), data...)
+	}
+	return newBufferedBody(data), nil
+}
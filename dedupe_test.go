@@ -0,0 +1,77 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeduplication(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		target   string
+		requests int32
+	}{
+		{"identical calls share a request", nil, "A.One", 1},
+		{"other targets are sent", nil, "A.Two", 2},
+		{"other headers are sent", []Option{WithHeader("X-Tenant", "other")}, "A.One", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			arrived := make(chan struct{}, 2)
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				arrived <- struct{}{}
+				<-release
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.Write([]byte(`{"target":"` + r.Header.Get("X-Amz-Target") + `","tenant":"` + r.Header.Get("X-Tenant") + `"}`))
+			}))
+			defer server.Close()
+			dedupe := WithDeduplication()
+
+			var wg sync.WaitGroup
+			responses := make([][]byte, 2)
+			call := func(i int, target string, opts ...Option) {
+				defer wg.Done()
+				data, err := AWSJSON(context.Background(), []byte(`{}`), "events", server.URL, target, "eu-west-1", testCreds,
+					append([]Option{dedupe}, opts...)...)
+				if err != nil {
+					t.Errorf("could not call: %v", err)
+				}
+				responses[i] = data
+			}
+			wg.Add(2)
+			go call(0, "A.One")
+			<-arrived
+			go call(1, tt.target, tt.opts...)
+			if tt.requests == 2 {
+				select {
+				case <-arrived:
+				case <-time.After(time.Second):
+					t.Error("the second call was merged into the first one")
+				}
+			} else {
+				// gives the second call the time to wait on the first one
+				time.Sleep(50 * time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("expected %d requests, got %d", tt.requests, got)
+			}
+			second := newOptions(tt.opts)
+			want := `{"target":"` + tt.target + `","tenant":"` + second.header.Get("X-Tenant") + `"}`
+			if string(responses[1]) != want {
+				t.Errorf("expected %s for the second call, got %s", want, responses[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package iamsigned
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// TrailingSlash tells what endpoint normalization does with a trailing slash in the path
+type TrailingSlash int
+
+const (
+	// KeepTrailingSlash leaves a trailing slash as is
+	KeepTrailingSlash TrailingSlash = iota
+	// StripTrailingSlash removes the trailing slash of non-root paths
+	StripTrailingSlash
+)
+
+var duplicateSlashes = regexp.MustCompile(`/{2,}`)
+
+// WithEndpointNormalization cleans the endpoint path up before the request is signed: duplicate slashes are collapsed
+// ("https://x//foo" becomes "https://x/foo"), an empty path becomes "/", and the trailing slash is handled according
+// to trailing. The canonical request then matches what API Gateway expects instead of failing with a 403.
+//
+// Without this option the endpoint is used exactly as given.
+func WithEndpointNormalization(trailing TrailingSlash) Option {
+	return func(o *options) {
+		o.normalizeEndpoint = true
+		o.trailingSlash = trailing
+	}
+}
+
+// WithQuery adds query string parameters to the endpoint, e.g. for API Gateway GET requests. They are merged with
+// the ones already in the endpoint, and signed as part of the canonical query string.
+func WithQuery(values url.Values) Option {
+	return func(o *options) {
+		if o.query == nil {
+			o.query = make(url.Values)
+		}
+		for key, v := range values {
+			o.query[key] = append(o.query[key], v...)
+		}
+	}
+}
+
+// WithQueryParam does the same as WithQuery for a single parameter
+func WithQueryParam(key, value string) Option {
+	return WithQuery(url.Values{key: {value}})
+}
+
+// endpoint returns the URL to send the request to
+func (o *options) endpoint(endpoint string) (string, error) {
+	if !o.normalizeEndpoint && len(o.query) == 0 {
+		return endpoint, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("could not parse endpoint: %w", err)
+	}
+	if len(o.query) > 0 {
+		query := u.Query()
+		for key, values := range o.query {
+			query[key] = append(query[key], values...)
+		}
+		// SigV4 wants spaces encoded as %20, not the + of url.Values
+		u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+	if !o.normalizeEndpoint {
+		return u.String(), nil
+	}
+	path := duplicateSlashes.ReplaceAllString(u.EscapedPath(), "/")
+	if o.trailingSlash == StripTrailingSlash && path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return "", fmt.Errorf("could not normalize endpoint path: %w", err)
+	}
+	u.Path = unescaped
+	u.RawPath = path
+	return u.String(), nil
+}
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+
+// RegionFromEndpoint infers the region from the host of a regional AWS endpoint, e.g.
+// xxx.appsync-api.eu-west-1.amazonaws.com, xxx.execute-api.us-east-1.amazonaws.com or xxx.lambda-url.eu-west-3.on.aws.
+// Custom domains carry no region, and return an error.
+func RegionFromEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("could not parse endpoint: %w", err)
+	}
+	for _, label := range strings.Split(u.Hostname(), ".") {
+		if regionPattern.MatchString(label) {
+			return label, nil
+		}
+	}
+	return "", fmt.Errorf("could not infer region from endpoint '%s'", endpoint)
+}
+
+// WithRegionResolver derives the signing region from the endpoint when a call is made with an empty region, e.g.
+// RegionFromEndpoint, or a function mapping custom domains and falling back to it
+func WithRegionResolver(resolve func(endpoint string) (string, error)) Option {
+	return func(o *options) {
+		o.regionResolver = resolve
+	}
+}
+
+// region returns the region to sign the request for
+func (o *options) region(endpoint, region string) (string, error) {
+	if region != "" || o.regionResolver == nil {
+		return region, nil
+	}
+	resolved, err := o.regionResolver(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve region: %w", err)
+	}
+	return resolved, nil
+}
+
+// escapePathSegment escapes every byte of s but the unreserved characters, as the AWS SDKs escape the ids they put
+// in request paths
+func escapePathSegment(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '-' || c == '_' || c == '.' || c == '~' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEndpointNormalization(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		path string
+		opts []Option
+		want string
+	}{
+		{"as given", "//prod//items/", nil, "//prod//items/"},
+		{"duplicate slashes", "//prod//items/", []Option{WithEndpointNormalization(KeepTrailingSlash)}, "/prod/items/"},
+		{"trailing slash", "/prod/items/", []Option{WithEndpointNormalization(StripTrailingSlash)}, "/prod/items"},
+		{"root", "/", []Option{WithEndpointNormalization(StripTrailingSlash)}, "/"},
+		{"empty", "", []Option{WithEndpointNormalization(KeepTrailingSlash)}, "/"},
+		{"escaped", "/prod//a%2Fb", []Option{WithEndpointNormalization(KeepTrailingSlash)}, "/prod/a%2Fb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL+tt.path, "eu-west-1", http.MethodGet,
+				testCreds, tt.opts...)
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if path != tt.want {
+				t.Errorf("got path %q, want %q", path, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegionFromEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		region   string
+	}{
+		{"https://abc.appsync-api.eu-west-1.amazonaws.com/graphql", "eu-west-1"},
+		{"https://abc.execute-api.us-east-1.amazonaws.com/prod", "us-east-1"},
+		{"https://abc.lambda-url.eu-west-3.on.aws/", "eu-west-3"},
+		{"https://abc.execute-api.us-gov-west-1.amazonaws.com/prod", "us-gov-west-1"},
+		{"https://api.example.com/graphql", ""},
+		{"://not a url", ""},
+	}
+	for _, tt := range tests {
+		region, err := RegionFromEndpoint(tt.endpoint)
+		if region != tt.region || (err != nil) != (tt.region == "") {
+			t.Errorf("RegionFromEndpoint(%q) = %q, %v, want %q", tt.endpoint, region, err, tt.region)
+		}
+	}
+}
+
+func TestRegionResolver(t *testing.T) {
+	var scope string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the credential scope is AKIDEXAMPLE/<date>/<region>/execute-api/aws4_request
+		scope = strings.Split(r.Header.Get("Authorization"), "/")[2]
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	resolver := WithRegionResolver(func(endpoint string) (string, error) {
+		if strings.HasPrefix(endpoint, server.URL) {
+			return "ap-southeast-2", nil
+		}
+		return RegionFromEndpoint(endpoint)
+	})
+
+	tests := []struct {
+		name   string
+		region string
+		opts   []Option
+		want   string
+	}{
+		{"resolved", "", []Option{resolver}, "ap-southeast-2"},
+		{"given region wins", "eu-west-1", []Option{resolver}, "eu-west-1"},
+		{"failing resolver", "", []Option{WithRegionResolver(RegionFromEndpoint)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope = ""
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, tt.region, http.MethodGet, testCreds,
+				tt.opts...)
+			if failed := err != nil; failed != (tt.want == "") {
+				t.Fatalf("got error %v", err)
+			}
+			if scope != tt.want {
+				t.Errorf("got request signed for %q, want %q", scope, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package iamsigned
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// contextError decorates an error with the metadata set by WithErrorContext
+type contextError struct {
+	err      error
+	metadata map[string]string
+}
+
+// WithErrorContext attaches caller-supplied metadata (correlation ID, tenant...) to every error returned for the
+// request. The entries are appended to the error message and can be read back with ErrorContext; they are never
+// sent on the wire.
+func WithErrorContext(metadata map[string]string) Option {
+	copied := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return func(o *options) {
+		if o.errorContext == nil {
+			o.errorContext = make(map[string]string, len(copied))
+		}
+		for k, v := range copied {
+			o.errorContext[k] = v
+		}
+	}
+}
+
+// ErrorContext returns the metadata attached to err by WithErrorContext, or nil
+func ErrorContext(err error) map[string]string {
+	var ce *contextError
+	if !errors.As(err, &ce) {
+		return nil
+	}
+	return ce.metadata
+}
+
+// wrapError attaches the request metadata, if any, to err
+func (o *options) wrapError(err error) error {
+	if err == nil || len(o.errorContext) == 0 {
+		return err
+	}
+	return &contextError{err: err, metadata: o.errorContext}
+}
+
+func (e *contextError) Error() string {
+	keys := make([]string, 0, len(e.metadata))
+	for k := range e.metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%s", k, e.metadata[k])
+	}
+	return fmt.Sprintf("%s [%s]", e.err.Error(), strings.Join(pairs, " "))
+}
+
+func (e *contextError) Unwrap() error {
+	return e.err
+}
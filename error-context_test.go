@@ -0,0 +1,60 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorContext(t *testing.T) {
+	var leaked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, values := range r.Header {
+			for _, value := range values {
+				leaked = leaked || strings.Contains(value, "corr-42")
+			}
+		}
+		http.Error(w, `{"message":"boom"}`, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	metadata := map[string]string{"tenant": "acme", "correlation_id": "corr-42"}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{"without context", nil, nil},
+		{"with context", []Option{WithErrorContext(metadata)}, metadata},
+		{"merged", []Option{WithErrorContext(map[string]string{"tenant": "acme"}),
+			WithErrorContext(map[string]string{"correlation_id": "corr-42"})}, metadata},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet,
+				testCreds, tt.opts...)
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+				t.Fatalf("got error %v, want the HTTPError through the context", err)
+			}
+			got := ErrorContext(err)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got metadata %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("got %s=%q, want %q", k, got[k], v)
+				}
+			}
+			if tt.want != nil && !strings.HasSuffix(err.Error(), "[correlation_id=corr-42 tenant=acme]") {
+				t.Errorf("got message %q, want the sorted metadata", err)
+			}
+		})
+	}
+	if leaked {
+		t.Error("the metadata was sent on the wire")
+	}
+}
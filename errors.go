@@ -0,0 +1,98 @@
+package iamsigned
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Errors returned by the package can be classified with errors.Is against the sentinels below, and inspected with
+// errors.As against *HTTPError (any non-successful status), *APIError (AWS error envelopes) or *GraphQLErrors.
+var (
+	// ErrSigning matches failures to sign a request, e.g. when credentials can't be retrieved
+	ErrSigning = errors.New("signing failed")
+	// ErrTransport matches failures to get a response at all (DNS, connection refused or reset, TLS...)
+	ErrTransport = errors.New("transport failed")
+	// ErrThrottled matches responses rejected by AWS throttling: a 429 status, or a throttling error code (e.g.
+	// ThrottlingException or TooManyRequestsException) in the headers, an error envelope or a GraphQL error
+	ErrThrottled = errors.New("request throttled")
+	// ErrRedirect matches redirect responses, which are only followed with WithRedirects
+	ErrRedirect = errors.New("request redirected")
+	// ErrGraphQL matches GraphQL responses holding errors
+	ErrGraphQL = errors.New("graphql errors")
+)
+
+// throttlingCodes are the error codes AWS services use for throttling
+var throttlingCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"TooManyRequestsException":               true,
+	"RequestLimitExceeded":                   true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"ProvisionedThroughputExceededException": true,
+	"SlowDown":                               true,
+}
+
+// isThrottlingCode tells whether an error code or type means throttling, e.g. "ThrottlingException" or AppSync's
+// "DynamoDB:ProvisionedThroughputExceededException"
+func isThrottlingCode(code string) bool {
+	if i := strings.LastIndex(code, ":"); i >= 0 {
+		code = code[i+1:]
+	}
+	return throttlingCodes[code]
+}
+
+// signingError is a failure to sign a request
+type signingError struct {
+	err error
+}
+
+func (e *signingError) Error() string {
+	return fmt.Sprintf("failed to sign the request: %s", e.err)
+}
+
+func (e *signingError) Unwrap() error {
+	return e.err
+}
+
+func (e *signingError) Is(target error) bool {
+	return target == ErrSigning
+}
+
+func (e *transportError) Is(target error) bool {
+	return target == ErrTransport
+}
+
+// Is matches ErrThrottled for throttled responses, and ErrRedirect for redirects
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests || isThrottlingCode(e.ErrorType())
+	case ErrRedirect:
+		return e.Location() != "" && e.StatusCode >= 300 && e.StatusCode < 400
+	}
+	return false
+}
+
+// Is matches ErrThrottled when the envelope holds a throttling code
+func (e *APIError) Is(target error) bool {
+	return target == ErrThrottled && isThrottlingCode(e.Code)
+}
+
+// Is matches ErrGraphQL, and ErrThrottled when one of the errors has a throttling type
+func (e *GraphQLErrors) Is(target error) bool {
+	switch target {
+	case ErrGraphQL:
+		return true
+	case ErrThrottled:
+		for _, err := range e.Errors {
+			if isThrottlingCode(err.ErrorType) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"golang.org/x/net/websocket"
+)
+
+const eventsProtocol = "aws-appsync-event-ws"
+
+type eventsPublishPayload struct {
+	Channel string   `json:"channel"`
+	Events  []string `json:"events"`
+}
+
+// PublishResult tells which events of a publish call AppSync accepted
+type PublishResult struct {
+	Successful []PublishedEvent `json:"successful"`
+	Failed     []FailedEvent    `json:"failed"`
+}
+
+// PublishedEvent is an event AppSync accepted, Index being its position in the call
+type PublishedEvent struct {
+	Identifier string `json:"identifier"`
+	Index      int    `json:"index"`
+}
+
+// FailedEvent is an event AppSync rejected, Index being its position in the call
+type FailedEvent struct {
+	Identifier string `json:"identifier"`
+	Index      int    `json:"index"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+// PublishEvents publishes events to a channel (e.g. "/default/orders") of an AppSync Events API, whose HTTP endpoint
+// is given, e.g. https://xxx.appsync-api.eu-west-1.amazonaws.com/event. Each event is marshaled to JSON, and AppSync
+// takes up to 5 events per call. Events that AppSync rejects are listed in the result, without failing the call.
+func PublishEvents(ctx context.Context, events []interface{}, endpoint, channel, region string, creds *credentials.Credentials, opts ...Option) (PublishResult, error) {
+	o := newOptions(opts)
+	var result PublishResult
+	publish := eventsPublishPayload{Channel: channel, Events: make([]string, len(events))}
+	for i, event := range events {
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			return result, o.wrapError(fmt.Errorf("could not encode event %d: %w", i, err))
+		}
+		publish.Events[i] = string(encoded)
+	}
+	payload, err := json.Marshal(publish)
+	if err != nil {
+		return result, o.wrapError(fmt.Errorf("could not encode events: %w", err))
+	}
+
+	o.expectJSON = true
+	data, err := deliverBytes(ctx, payload, AppSyncService, endpoint, region, http.MethodPost, creds, o)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, o.wrapError(fmt.Errorf("could not parse response, expected JSON: '%s': %w", snippet(data), err))
+	}
+	return result, nil
+}
+
+// EventsClient is an IAM-authenticated connection to the real-time endpoint of an AppSync Events API, over which
+// channels are subscribed to. It watches keep-alive messages, and fails every subscription when they stop.
+//
+// Requests go through a dedicated WebSocket connection: WithHTTPClient and the transport options other than
+// WithTLSConfig don't apply.
+type EventsClient struct {
+	conn     *realtimeConn
+	endpoint string
+	region   string
+	creds    *credentials.Credentials
+	o        *options
+}
+
+// EventSubscription is a channel subscription registered on an EventsClient. The data of its events is the JSON
+// event as published.
+type EventSubscription struct {
+	ID      string
+	Channel string
+	client  *EventsClient
+	sub     *realtimeSubscription
+}
+
+// DialEvents connects to the real-time endpoint of the AppSync Events API whose HTTP endpoint is given, e.g.
+// https://xxx.appsync-api.eu-west-1.amazonaws.com/event (custom domains are supported too)
+func DialEvents(ctx context.Context, endpoint, region string, creds *credentials.Credentials, opts ...Option) (*EventsClient, error) {
+	o := newOptions(opts)
+	endpoint, region, creds, err := o.prepare(endpoint, region, creds)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	c := &EventsClient{endpoint: endpoint, region: region, creds: creds, o: o}
+	if err := c.connect(ctx); err != nil {
+		return nil, o.wrapError(err)
+	}
+	go c.conn.readLoop(c.dispatch)
+	return c, nil
+}
+
+// connect opens the WebSocket, authenticated through its subprotocol, and performs the connection_init handshake
+func (c *EventsClient) connect(ctx context.Context) error {
+	api, err := url.Parse(c.endpoint)
+	if err != nil {
+		return fmt.Errorf("could not parse endpoint: %w", err)
+	}
+	headers, err := realtimeAuthorization(ctx, c.endpoint, []byte("{}"), c.region, c.creds, c.o)
+	if err != nil {
+		return err
+	}
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("could not encode authorization: %w", err)
+	}
+
+	config, err := websocket.NewConfig(eventsRealtimeURL(api).String(), "https://"+api.Host)
+	if err != nil {
+		return fmt.Errorf("could not configure connection: %w", err)
+	}
+	config.Protocol = []string{eventsProtocol, "header-" + base64.RawURLEncoding.EncodeToString(encodedHeaders)}
+	config.TlsConfig = c.o.transport.tlsConfig
+	c.conn, err = dialRealtime(ctx, config)
+	return err
+}
+
+// eventsRealtimeURL derives the real-time endpoint from the HTTP one
+func eventsRealtimeURL(api *url.URL) *url.URL {
+	u := *api
+	u.Scheme = "wss"
+	u.Host = strings.Replace(u.Host, ".appsync-api.", ".appsync-realtime-api.", 1)
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/realtime"
+	return &u
+}
+
+// Subscribe subscribes to a channel, or a namespace prefix such as "/default/*". Events are delivered on the
+// subscription channel until it's closed, or the connection fails.
+func (c *EventsClient) Subscribe(ctx context.Context, channel string) (*EventSubscription, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, c.o.wrapError(err)
+	}
+	payload, err := json.Marshal(struct {
+		Channel string `json:"channel"`
+	}{channel})
+	if err != nil {
+		return nil, c.o.wrapError(fmt.Errorf("could not encode subscription: %w", err))
+	}
+	headers, err := realtimeAuthorization(ctx, c.endpoint, payload, c.region, c.creds, c.o)
+	if err != nil {
+		return nil, c.o.wrapError(err)
+	}
+
+	start := realtimeMessage{ID: id, Type: "subscribe", Channel: channel, Authorization: headers}
+	sub, err := c.conn.subscribe(ctx, id, start, "unsubscribe")
+	if err != nil {
+		return nil, c.o.wrapError(err)
+	}
+	return &EventSubscription{ID: id, Channel: channel, client: c, sub: sub}, nil
+}
+
+// Events returns the channel events are delivered on
+func (s *EventSubscription) Events() <-chan SubscriptionEvent {
+	return s.sub.events
+}
+
+// Close unsubscribes
+func (s *EventSubscription) Close() error {
+	if !s.client.conn.remove(s.ID) {
+		return nil
+	}
+	return s.client.conn.send(realtimeMessage{ID: s.ID, Type: "unsubscribe"})
+}
+
+// Err returns the error that ended the connection, if any
+func (c *EventsClient) Err() error {
+	return c.conn.Err()
+}
+
+// Close closes the connection, ending every subscription
+func (c *EventsClient) Close() error {
+	return c.conn.Close()
+}
+
+// dispatch handles a message received on the connection
+func (c *EventsClient) dispatch(msg realtimeMessage) error {
+	switch msg.Type {
+	case "subscribe_success":
+		c.conn.acknowledge(msg.ID, nil)
+	case "subscribe_error":
+		c.conn.acknowledge(msg.ID, realtimeError(msg))
+	case "data":
+		// the event is the published JSON, itself encoded as a string
+		var event string
+		if err := json.Unmarshal(msg.Event, &event); err != nil {
+			c.conn.deliver(msg.ID, SubscriptionEvent{Data: msg.Event})
+			return nil
+		}
+		c.conn.deliver(msg.ID, SubscriptionEvent{Data: json.RawMessage(event)})
+	case "broadcast_error", "error":
+		c.conn.deliver(msg.ID, SubscriptionEvent{Err: realtimeError(msg)})
+	case "connection_error":
+		return fmt.Errorf("realtime connection error: %w", realtimeError(msg))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func eventData(id string) realtimeMessage {
+	return realtimeMessage{ID: id, Type: "data", Event: json.RawMessage(`"{\"order\":1}"`)}
+}
+
+func TestEventSubscriptionEvents(t *testing.T) {
+	server := newFakeRealtime(t, "subscribe_success", eventData)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := DialEvents(ctx, server.URL+"/event", "eu-west-1", testCreds, server.trust())
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer client.Close()
+	sub, err := client.Subscribe(ctx, "/default/orders")
+	if err != nil {
+		t.Fatalf("could not subscribe: %v", err)
+	}
+
+	event := <-sub.Events()
+	if event.Err != nil || string(event.Data) != `{"order":1}` {
+		t.Errorf("got event %s (%v)", event.Data, event.Err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("could not close subscription: %v", err)
+	}
+}
+
+// TestEventSubscriptionCloseWhileDelivering is TestSubscriptionCloseWhileDelivering for Events. Run with -race.
+func TestEventSubscriptionCloseWhileDelivering(t *testing.T) {
+	server := newFakeRealtime(t, "subscribe_success", eventData)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := DialEvents(ctx, server.URL+"/event", "eu-west-1", testCreds, server.trust())
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 50; i++ {
+		sub, err := client.Subscribe(ctx, "/default/orders")
+		if err != nil {
+			t.Fatalf("could not subscribe %d: %v", i, err)
+		}
+		<-sub.Events()
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sub.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			for range sub.Events() {
+			}
+		}()
+		wg.Wait()
+	}
+	if err := client.Err(); err != nil {
+		t.Errorf("connection failed: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// RegionalEndpoint is an endpoint along with the region requests to it are signed for
+type RegionalEndpoint struct {
+	Endpoint string
+	Region   string
+}
+
+// WithFailover sends the request to the next of the fallback endpoints, in order, when the call to the previous one
+// failed with a transport error, a 5xx response, or an open circuit (see WithCircuitBreaker); any other failure is
+// returned right away. Requests are signed again for the region of each endpoint, and every endpoint gets its own
+// attempts (see WithMaxAttempts). Streamed bodies that can't be rewound are never resent.
+//
+// The endpoint given to the call (or to NewClient) is the primary one, e.g. for active/passive APIs in two regions:
+//
+//	client := iamsigned.NewClient(primaryEndpoint, "eu-west-1", creds,
+//		iamsigned.WithFailover(iamsigned.RegionalEndpoint{Endpoint: secondaryEndpoint, Region: "eu-central-1"}))
+func WithFailover(fallbacks ...RegionalEndpoint) Option {
+	return func(o *options) {
+		o.fallbacks = append([]RegionalEndpoint(nil), fallbacks...)
+	}
+}
+
+// deliverFailover delivers the request to the primary endpoint, then to the fallbacks while it fails over
+func deliverFailover(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (io.ReadCloser, error) {
+	body, err := deliverAttempts(ctx, payload, service, endpoint, region, method, creds, o)
+	for _, fallback := range o.fallbacks {
+		if err == nil || ctx.Err() != nil || !o.resendable() || !failsOver(err) {
+			break
+		}
+		o.logFailover(ctx, fallback, err)
+		body, err = deliverAttempts(ctx, payload, service, fallback.Endpoint, fallback.Region, method, creds, o)
+	}
+	return body, err
+}
+
+// failsOver tells whether the next endpoint should be tried after err
+func failsOver(err error) bool {
+	var te *transportError
+	var he *HTTPError
+	switch {
+	case errors.As(err, &te), errors.Is(err, ErrCircuitOpen):
+		return true
+	case errors.As(err, &he):
+		return he.StatusCode >= 500
+	default:
+		return false
+	}
+}
@@ -0,0 +1,66 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFailover(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		status   int
+		down     bool
+		failover bool
+	}{
+		{"5xx", http.StatusServiceUnavailable, false, true},
+		{"connection error", 0, true, true},
+		{"4xx", http.StatusForbidden, false, false},
+		{"success", http.StatusOK, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"from":"primary"}`))
+			}))
+			defer primary.Close()
+			primaryURL := primary.URL
+			if tt.down {
+				primaryURL = closed.URL
+			}
+			var fallbackAuth, fallbackHost string
+			fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fallbackAuth, fallbackHost = r.Header.Get(authorizationHeader), r.Host
+				w.Write([]byte(`{"from":"fallback"}`))
+			}))
+			defer fallback.Close()
+
+			data, err := APIGatewayWithContext(context.Background(), nil, primaryURL, "eu-west-1", http.MethodGet, testCreds,
+				WithMaxAttempts(1), WithFailover(RegionalEndpoint{Endpoint: fallback.URL, Region: "eu-central-1"}))
+			if !tt.failover {
+				if fallbackAuth != "" {
+					t.Fatal("the call failed over")
+				}
+				if wantErr := tt.status != http.StatusOK; (err != nil) != wantErr {
+					t.Errorf("got error %v, want failure: %v", err, wantErr)
+				}
+				return
+			}
+			if err != nil || string(data) != `{"from":"fallback"}` {
+				t.Fatalf("got %s, %v, want the response of the fallback", data, err)
+			}
+			if !strings.Contains(fallbackAuth, "/eu-central-1/execute-api/aws4_request") {
+				t.Errorf("the fallback request was not signed for its region: %s", fallbackAuth)
+			}
+			if fallbackHost != strings.TrimPrefix(fallback.URL, "http://") {
+				t.Errorf("the fallback request was sent to %s", fallbackHost)
+			}
+		})
+	}
+}
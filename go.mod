@@ -1,8 +1,21 @@
 module github.com/aherve/iamsigned
 
-go 1.16
+go 1.21
 
 require (
 	github.com/aws/aws-sdk-go v1.42.39
-	golang.org/x/net v0.0.0-20220121210141-e204ce36a2ba
+	github.com/aws/aws-sdk-go-v2 v1.26.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+	golang.org/x/net v0.24.0
+	golang.org/x/sync v0.6.0
+)
+
+require (
+	github.com/aws/smithy-go v1.20.2 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
 )
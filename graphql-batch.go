@@ -0,0 +1,71 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// AppSyncBatched sends all the operations as a JSON array in a single signed request, and splits the array response
+// back into one result per operation, in the same order. The returned error is set when the request as a whole failed;
+// the GraphQL errors of each operation are reported by its BatchResult, as a *GraphQLErrors.
+func AppSyncBatched(ctx context.Context, reqs []GraphQLRequest, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]BatchResult, error) {
+	o := newOptions(opts)
+	payload, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, o.wrapError(fmt.Errorf("could not encode GraphQL requests: %w", err))
+	}
+
+	o.buffer = true
+	o.expectJSON = true
+	ctx, end := o.startCall(ctx, AppSyncService, endpoint, region, http.MethodPost)
+	results, err := appSyncBatched(ctx, payload, len(reqs), endpoint, region, creds, o)
+	end(err)
+	return results, o.wrapError(err)
+}
+
+func appSyncBatched(ctx context.Context, payload []byte, count int, endpoint, region string, creds *credentials.Credentials, o *options) ([]BatchResult, error) {
+	body, err := deliverWithContext(ctx, payload, AppSyncService, endpoint, region, http.MethodPost, creds, o)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read buffer: %w", err)
+	}
+	return parseGraphQLBatch(data, count, o)
+}
+
+// parseGraphQLBatch splits the array response of a batched request
+func parseGraphQLBatch(data []byte, count int, o *options) ([]BatchResult, error) {
+	// an API that doesn't support batching answers with a single response, usually holding the error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		_, err := ParseGraphQLResponse(newBufferedBody(data))
+		if err == nil {
+			err = fmt.Errorf("could not parse response, expected a JSON array: '%s'", snippet(data))
+		}
+		return nil, err
+	}
+
+	var parsed []graphqlResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("could not parse response, expected JSON: '%s': %w", snippet(data), err)
+	}
+	if len(parsed) != count {
+		return nil, fmt.Errorf("could not split response: got %d results for %d operations", len(parsed), count)
+	}
+
+	results := make([]BatchResult, count)
+	for i, response := range parsed {
+		errs, err := newGraphQLErrors(response.Errors)
+		results[i] = BatchResult{Data: response.Data, Err: errs.orError(err)}
+		if results[i].Err == nil {
+			results[i].Err = o.validateData(response.Data)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,212 @@
+package iamsigned
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// GraphQLError is a single entry of the "errors" field of a GraphQL response
+	GraphQLError struct {
+		Locations []GraphQLErrorLocation `json:"locations"`
+		Message   string                 `json:"message"`
+		// Path is the response field the error relates to, made of field names (strings) and list indexes (numbers)
+		Path []interface{} `json:"path,omitempty"`
+		// ErrorType is the AppSync error classification (e.g. "UnauthorizedException")
+		ErrorType string `json:"errorType,omitempty"`
+		// ErrorCode is the numeric error code, when the server sends one. AppSync may encode it as a number or a
+		// string, both are accepted.
+		ErrorCode *int `json:"errorCode,omitempty"`
+		// Data and ErrorInfo are the extra values an AppSync resolver passes to $util.error / $util.appendError
+		Data      json.RawMessage `json:"data,omitempty"`
+		ErrorInfo json.RawMessage `json:"errorInfo,omitempty"`
+		// Extensions is the raw "extensions" object of the error. AppSync fields found there (errorType, errorCode,
+		// errorInfo) are also exposed through the fields above.
+		Extensions json.RawMessage `json:"extensions,omitempty"`
+	}
+
+	// GraphQLErrorLocation points to the part of the query an error relates to
+	GraphQLErrorLocation struct {
+		Column int `json:"column"`
+		Line   int `json:"line"`
+	}
+
+	// GraphQLErrors is returned when a GraphQL response holds at least one error. Use errors.As to inspect them.
+	GraphQLErrors struct {
+		Errors []GraphQLError
+		// Raw holds the "errors" field exactly as the server sent it, key order and whitespace included, e.g. for
+		// golden files
+		Raw json.RawMessage
+	}
+)
+
+// newGraphQLErrors decodes the raw "errors" field of a response. It returns nil when there is no error.
+func newGraphQLErrors(raw json.RawMessage) (*GraphQLErrors, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var errs []GraphQLError
+	if err := json.Unmarshal(raw, &errs); err != nil {
+		return nil, fmt.Errorf("could not parse errors: %w", err)
+	}
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return &GraphQLErrors{Errors: errs, Raw: raw}, nil
+}
+
+// orError returns err if set, then e, as an error which is nil when both are
+func (e *GraphQLErrors) orError(err error) error {
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
+func (e *GraphQLErrors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GraphQL returned %v error(s)", len(e.Errors))
+	for _, err := range e.Errors {
+		fmt.Fprintf(&b, "\n %+v: %s", err.Locations, err.Message)
+		if len(err.Path) > 0 {
+			fmt.Fprintf(&b, " (path %s)", err.PathString())
+		}
+	}
+	return b.String()
+}
+
+// PathString renders the path of the error, e.g. "posts.2.author". It's empty when the error has no path.
+func (e GraphQLError) PathString() string {
+	parts := make([]string, len(e.Path))
+	for i, segment := range e.Path {
+		parts[i] = fmt.Sprint(segment)
+	}
+	return strings.Join(parts, ".")
+}
+
+// PipelineStack returns the names of the pipeline functions an AppSync error went through, as reported in the
+// "stack" entry of its errorInfo. It returns nil when the error carries no such detail.
+func (e GraphQLError) PipelineStack() []string {
+	var info struct {
+		Stack []string `json:"stack"`
+	}
+	if len(e.ErrorInfo) == 0 || json.Unmarshal(e.ErrorInfo, &info) != nil {
+		return nil
+	}
+	return info.Stack
+}
+
+// DecodeErrorInfo unmarshals the AppSync errorInfo of the error into v
+func (e GraphQLError) DecodeErrorInfo(v interface{}) error {
+	if len(e.ErrorInfo) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.ErrorInfo, v)
+}
+
+// UnmarshalJSON decodes a GraphQL error, being lenient with the errorCode encoding
+func (e *GraphQLError) UnmarshalJSON(data []byte) error {
+	type plain GraphQLError
+	var raw struct {
+		plain
+		ErrorCode json.RawMessage `json:"errorCode"`
+	}
+	var extensions struct {
+		ErrorType string          `json:"errorType"`
+		ErrorCode json.RawMessage `json:"errorCode"`
+		ErrorInfo json.RawMessage `json:"errorInfo"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = GraphQLError(raw.plain)
+	if len(e.Extensions) > 0 {
+		// extensions that aren't an object are kept raw, but don't feed the AppSync fields
+		json.Unmarshal(e.Extensions, &extensions)
+	}
+
+	if e.ErrorType == "" {
+		e.ErrorType = extensions.ErrorType
+	}
+	if len(e.ErrorInfo) == 0 || string(e.ErrorInfo) == "null" {
+		e.ErrorInfo = extensions.ErrorInfo
+	}
+	code := raw.ErrorCode
+	if len(code) == 0 {
+		code = extensions.ErrorCode
+	}
+	e.ErrorCode = parseErrorCode(code)
+	return nil
+}
+
+// parseErrorCode accepts integers, floats and numeric strings. Anything else is treated as absent.
+func parseErrorCode(raw json.RawMessage) *int {
+	var value interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &value) != nil {
+		return nil
+	}
+
+	var code int
+	switch v := value.(type) {
+	case float64:
+		code = int(v)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		code = int(f)
+	default:
+		return nil
+	}
+	return &code
+}
+
+// flattenedGraphQLErrors summarizes GraphQL errors on a single line, and unwraps to *GraphQLErrors
+type flattenedGraphQLErrors struct {
+	errs *GraphQLErrors
+}
+
+// FlattenGraphQLErrors joins errs into a single, one-line error suited for logs and alerts, e.g.
+// "2 errors: [UnauthorizedException] msg1; [Conflict 409] msg2". The original errors remain available through
+// errors.As with a *GraphQLErrors target. It returns nil when errs is empty.
+func FlattenGraphQLErrors(errs []GraphQLError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &flattenedGraphQLErrors{errs: &GraphQLErrors{Errors: errs}}
+}
+
+func (e *flattenedGraphQLErrors) Error() string {
+	errs := e.errs.Errors
+	parts := make([]string, 0, len(errs))
+	for _, err := range errs {
+		var labels []string
+		if err.ErrorType != "" {
+			labels = append(labels, err.ErrorType)
+		}
+		if err.ErrorCode != nil {
+			labels = append(labels, strconv.Itoa(*err.ErrorCode))
+		}
+		if len(labels) == 0 {
+			parts = append(parts, err.Message)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("[%s] %s", strings.Join(labels, " "), err.Message))
+	}
+
+	noun := "errors"
+	if len(errs) == 1 {
+		noun = "error"
+	}
+	return fmt.Sprintf("%d %s: %s", len(errs), noun, strings.Join(parts, "; "))
+}
+
+func (e *flattenedGraphQLErrors) Unwrap() error {
+	return e.errs
+}
@@ -0,0 +1,125 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// appSyncServer answers every request with body, as AppSync does: 200 OK, errors included
+func appSyncServer(t *testing.T, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// appSyncErrors calls server, and returns the GraphQL errors of the response
+func appSyncErrors(t *testing.T, server *httptest.Server) *GraphQLErrors {
+	_, err := AppSyncWithContext(context.Background(), []byte(`{"query":"{ getPost { id } }"}`), server.URL,
+		"eu-west-1", testCreds)
+	var errs *GraphQLErrors
+	if !errors.As(err, &errs) || len(errs.Errors) != 1 {
+		t.Fatalf("got error %v, want a single GraphQL error", err)
+	}
+	return errs
+}
+
+func TestGraphQLErrorTypeAndCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       string
+		errorType string
+		errorCode int
+	}{
+		{"top-level number", `{"message":"m","errorType":"Conflict","errorCode":409}`, "Conflict", 409},
+		{"top-level string", `{"message":"m","errorType":"Conflict","errorCode":"409"}`, "Conflict", 409},
+		{"float", `{"message":"m","errorCode":404.0}`, "", 404},
+		{"extensions", `{"message":"m","extensions":{"errorType":"Unauthorized","errorCode":401}}`, "Unauthorized", 401},
+		{"top-level wins", `{"message":"m","errorCode":400,"extensions":{"errorCode":500}}`, "", 400},
+		{"not a number", `{"message":"m","errorCode":"oops"}`, "", 0},
+		{"absent", `{"message":"m"}`, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appSyncErrors(t, appSyncServer(t, `{"data":null,"errors":[`+tt.err+`]}`)).Errors[0]
+			if got.ErrorType != tt.errorType {
+				t.Errorf("got errorType %q, want %q", got.ErrorType, tt.errorType)
+			}
+			code := 0
+			if got.ErrorCode != nil {
+				code = *got.ErrorCode
+			}
+			if code != tt.errorCode {
+				t.Errorf("got errorCode %d, want %d", code, tt.errorCode)
+			}
+		})
+	}
+}
+
+func TestResolverErrorDetails(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   string
+		data  string
+		stack []string
+		info  string
+	}{
+		{"util.error", `{"message":"m","data":{"id":"1"},"errorInfo":{"stack":["validate","save"],"retryable":true}}`,
+			`{"id":"1"}`, []string{"validate", "save"}, "retryable"},
+		{"extensions", `{"message":"m","extensions":{"errorInfo":{"stack":["auth"],"retryable":false}}}`,
+			"", []string{"auth"}, "not retryable"},
+		{"no details", `{"message":"m"}`, "", nil, ""},
+		{"info without stack", `{"message":"m","errorInfo":{"retryable":true}}`, "", nil, "retryable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appSyncErrors(t, appSyncServer(t, `{"data":null,"errors":[`+tt.err+`]}`)).Errors[0]
+			if string(got.Data) != tt.data {
+				t.Errorf("got data %s, want %s", got.Data, tt.data)
+			}
+			if stack := got.PipelineStack(); strings.Join(stack, ",") != strings.Join(tt.stack, ",") {
+				t.Errorf("got stack %q, want %q", stack, tt.stack)
+			}
+			var info struct {
+				Retryable *bool `json:"retryable"`
+			}
+			if err := got.DecodeErrorInfo(&info); err != nil {
+				t.Fatalf("could not decode errorInfo: %v", err)
+			}
+			retryable := ""
+			if info.Retryable != nil && *info.Retryable {
+				retryable = "retryable"
+			} else if info.Retryable != nil {
+				retryable = "not retryable"
+			}
+			if retryable != tt.info {
+				t.Errorf("got errorInfo %s, want %s", got.ErrorInfo, tt.info)
+			}
+		})
+	}
+}
+
+func TestGraphQLErrorsRaw(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"key order", `[{"path":["getPost"],"message":"Unauthorized","errorType":"Unauthorized"}]`},
+		{"whitespace", "[\n  {\"message\": \"Unauthorized\"}\n]"},
+		{"unknown fields", `[{"message":"m","zeta":1,"alpha":{"b":2,"a":1}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := appSyncErrors(t, appSyncServer(t, `{"data":null,"errors":`+tt.raw+`}`))
+			if string(errs.Raw) != tt.raw {
+				t.Errorf("got raw errors %s, want them untouched: %s", errs.Raw, tt.raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// GraphQLRequest is the body of a GraphQL query, mutation or subscription. Variables can be any value encoding/json
+// marshals to an object, e.g. a map or a struct.
+type GraphQLRequest struct {
+	Query         string      `json:"query"`
+	Variables     interface{} `json:"variables,omitempty"`
+	OperationName string      `json:"operationName,omitempty"`
+}
+
+// Payload marshals the request into the JSON body AppSync expects
+func (r GraphQLRequest) Payload() ([]byte, error) {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode GraphQL request: %w", err)
+	}
+	return payload, nil
+}
+
+// AppSyncQuery marshals req, then does the same as AppSyncWithContext. See WithPersistedQueries to send the hash of
+// the query instead.
+func AppSyncQuery(ctx context.Context, req GraphQLRequest, endpoint, region string, creds *credentials.Credentials, opts ...Option) (json.RawMessage, error) {
+	o := newOptions(opts)
+	if o.persisted != nil {
+		return appSyncPersisted(ctx, req, endpoint, region, creds, o, opts)
+	}
+	payload, err := req.Payload()
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	return AppSyncWithContext(ctx, payload, endpoint, region, creds, opts...)
+}
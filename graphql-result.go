@@ -0,0 +1,51 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// GraphQLResult is a GraphQL response holding data, errors, or both
+type GraphQLResult struct {
+	// Data is the "data" field of the response. It may be null, or hold null fields where errors occurred.
+	Data json.RawMessage
+	// Errors are the GraphQL errors of the response, if any
+	Errors []GraphQLError
+	// Partial is set when the response holds both errors and data: the fields the errors point to (see
+	// GraphQLError.Path) are null, and the rest of the data can be used
+	Partial bool
+}
+
+// AppSyncResult sends req like AppSyncQuery, but reports GraphQL errors in the result instead of the error, next to
+// the data they came with, so partial results can be consumed knowingly. The error is only set when no GraphQL
+// response was received, e.g. on signing, transport or HTTP status failures.
+func AppSyncResult(ctx context.Context, req GraphQLRequest, endpoint, region string, creds *credentials.Credentials, opts ...Option) (GraphQLResult, error) {
+	data, err := AppSyncQuery(ctx, req, endpoint, region, creds, opts...)
+	return newGraphQLResult(data, err)
+}
+
+// newGraphQLResult moves the GraphQL errors of err into the result
+func newGraphQLResult(data json.RawMessage, err error) (GraphQLResult, error) {
+	var errs *GraphQLErrors
+	if err != nil && !errors.As(err, &errs) {
+		return GraphQLResult{}, err
+	}
+
+	result := GraphQLResult{Data: data}
+	if errs != nil {
+		result.Errors = errs.Errors
+		result.Partial = !isNull(data)
+	}
+	return result, nil
+}
+
+// Err returns the errors of the result as a *GraphQLErrors, or nil when there is none
+func (r GraphQLResult) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return &GraphQLErrors{Errors: r.Errors}
+}
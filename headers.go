@@ -0,0 +1,97 @@
+package iamsigned
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// hopByHopHeaders only make sense for a single connection: signing and sending them is never what the caller wants
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// WithHeader sets a header on the request before it's signed, so it's part of the signature (e.g. x-api-key or a
+// correlation ID). It overrides the Content-Type too (see WithContentType). Given to NewClient, the header is sent with
+// every request of the Client, and a per-call WithHeader of the same key replaces it.
+//
+// Hop-by-hop headers (Connection, Keep-Alive, Proxy-Authenticate, Proxy-Authorization, Proxy-Connection, TE,
+// Trailer, Transfer-Encoding, Upgrade, and any header listed in Connection) are dropped with a warning, as signing
+// them breaks the signature once the transport rewrites them.
+func WithHeader(key, value string) Option {
+	return func(o *options) {
+		o.setHeader(key, value)
+	}
+}
+
+// WithHeaders does the same as WithHeader for every key of header, keeping all its values
+func WithHeaders(header http.Header) Option {
+	return func(o *options) {
+		for key, values := range header {
+			if len(values) == 0 {
+				continue
+			}
+			o.setHeader(key, values[0])
+			for _, value := range values[1:] {
+				o.header.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithLogger sets the logger the package reports to: the start and completion of calls, retries, failures and
+// warnings, at the levels set with WithLogLevels. Payloads are only logged with WithPayloadLogging. Nothing is logged
+// by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// sanitizeHeaders removes hop-by-hop headers from the extra headers
+func (o *options) sanitizeHeaders() {
+	if len(o.header) == 0 {
+		return
+	}
+	stripped := append([]string(nil), hopByHopHeaders...)
+	for _, value := range o.header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				stripped = append(stripped, name)
+			}
+		}
+	}
+	for _, name := range stripped {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := o.header[name]; ok {
+			o.log().Warn("dropping hop-by-hop header", "header", name)
+			delete(o.header, name)
+		}
+	}
+}
+
+// log returns the configured logger, or one discarding everything
+func (o *options) log() *slog.Logger {
+	if o.logger == nil {
+		return discardLogger
+	}
+	return o.logger
+}
+
+var discardLogger = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
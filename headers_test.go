@@ -0,0 +1,51 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHopByHopHeaders(t *testing.T) {
+	server, header := headerServer(t)
+	tests := []struct {
+		name    string
+		opts    []Option
+		kept    string
+		dropped string
+	}{
+		{"custom header", []Option{WithHeader("X-Correlation-Id", "corr-1")}, "X-Correlation-Id", ""},
+		{"keep-alive", []Option{WithHeader("Keep-Alive", "timeout=5")}, "", "Keep-Alive"},
+		{"proxy authorization", []Option{WithHeader("proxy-authorization", "Basic eA==")}, "", "Proxy-Authorization"},
+		{"listed in Connection", []Option{
+			WithHeaders(http.Header{"Connection": {"X-Hop"}, "X-Hop": {"1"}, "X-Kept": {"1"}}),
+		}, "X-Kept", "X-Hop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			opts := append([]Option{WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))}, tt.opts...)
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				opts...)
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			signedHeaders := header.Get("Authorization")
+			if tt.kept != "" && (header.Get(tt.kept) == "" || !strings.Contains(signedHeaders, strings.ToLower(tt.kept))) {
+				t.Errorf("%s was not sent and signed: %q", tt.kept, signedHeaders)
+			}
+			if tt.dropped == "" {
+				return
+			}
+			if header.Get(tt.dropped) != "" || strings.Contains(signedHeaders, strings.ToLower(tt.dropped)) {
+				t.Errorf("%s was sent: %q", tt.dropped, signedHeaders)
+			}
+			if !strings.Contains(logs.String(), "dropping hop-by-hop header") {
+				t.Errorf("no warning was logged: %s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// WithHedging sends a second, separately signed copy of an attempt when the first one hasn't got a response after
+// delay, and keeps whichever responds first, canceling the other. This cuts the tail latency of slow calls, at the
+// cost of extra load.
+//
+// Only idempotent calls are hedged: those with an idempotent method (GET, HEAD, OPTIONS, PUT or DELETE), and those
+// marked with WithIdempotencyKey, as needed by GraphQL queries, sent with POST. Streamed bodies are never hedged.
+func WithHedging(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedgeDelay = delay
+	}
+}
+
+// hedges tells whether the attempts of a call with the given method are hedged
+func (o *options) hedges(method string) bool {
+	if o.hedgeDelay <= 0 || o.body != nil {
+		return false
+	}
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return o.idempotent
+}
+
+// hedgedCopy is the outcome of a copy of the attempt
+type hedgedCopy struct {
+	index    int
+	req      *http.Request
+	response *http.Response
+	err      error
+	latency  time.Duration
+}
+
+// sendHedged sends the attempt, hedging it after the configured delay. Copies are built and signed on the calling
+// goroutine, so the options are never accessed concurrently.
+func sendHedged(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (*http.Response, error) {
+	results := make(chan hedgedCopy, 2)
+	var cancels []context.CancelFunc
+	launch := func(sendCtx context.Context) error {
+		if err := o.waitRateLimit(ctx); err != nil {
+			return err
+		}
+		req, err := newSignedRequest(ctx, payload, service, endpoint, region, method, creds, o)
+		if err != nil {
+			return err
+		}
+		client, err := o.sendingClient(service, region, creds)
+		if err != nil {
+			return err
+		}
+		sendCtx, cancel := context.WithCancel(sendCtx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		start := time.Now()
+		go func() {
+			response, err := client.Do(req.WithContext(sendCtx))
+			results <- hedgedCopy{index: index, req: req, response: response, err: err, latency: time.Since(start)}
+		}()
+		return nil
+	}
+
+	// only the first copy reports its connection to ResponseInfo
+	if err := launch(o.traceContext(ctx)); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(o.hedgeDelay)
+	defer timer.Stop()
+
+	var winner hedgedCopy
+	select {
+	case winner = <-results:
+	case <-timer.C:
+		// the hedge is best effort: if it can't be sent, the first copy carries on alone
+		launch(ctx)
+		winner = <-results
+	}
+	pending := len(cancels) - 1
+	if winner.err != nil && pending > 0 && ctx.Err() == nil {
+		// a failed copy doesn't win over one still in flight
+		cancels[winner.index]()
+		winner = <-results
+		pending--
+	}
+	for i, cancel := range cancels {
+		if i != winner.index {
+			cancel()
+		}
+	}
+	if pending > 0 {
+		go func() {
+			if loser := <-results; loser.response != nil {
+				loser.response.Body.Close()
+			}
+		}()
+	}
+
+	o.afterResponse(ctx, winner.req, winner.response, winner.err, winner.latency)
+	o.recordOutcome(ctx, endpoint, winner.response, winner.err)
+	if winner.err != nil {
+		cancels[winner.index]()
+		return nil, &transportError{err: winner.err}
+	}
+	winner.response.Body = &cancelOnClose{ReadCloser: winner.response.Body, cancel: cancels[winner.index]}
+	o.recordResponse(winner.response)
+	return winner.response, nil
+}
@@ -0,0 +1,111 @@
+package iamsigned
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// trackedBody tells whether it was closed
+type trackedBody struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed.Store(true)
+	return nil
+}
+
+func TestHedging(t *testing.T) {
+	var requests atomic.Int32
+	primaryCanceled := make(chan struct{})
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if requests.Add(1) == 1 {
+			// the primary copy only ends once the hedge won
+			<-req.Context().Done()
+			close(primaryCanceled)
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"copy":"hedge"}`))}, nil
+	})}
+
+	data, err := APIGatewayWithContext(context.Background(), nil, "https://api.example.com/items", "eu-west-1",
+		http.MethodGet, testCreds, WithHTTPClient(client), WithHedging(10*time.Millisecond))
+	if err != nil || string(data) != `{"copy":"hedge"}` {
+		t.Fatalf("got %s, %v, want the response of the hedge", data, err)
+	}
+	select {
+	case <-primaryCanceled:
+	case <-time.After(time.Second):
+		t.Error("the slow primary copy was not canceled")
+	}
+}
+
+func TestHedgingClosesLoser(t *testing.T) {
+	var requests atomic.Int32
+	loser := &trackedBody{Reader: strings.NewReader(`{"copy":"primary"}`)}
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if requests.Add(1) == 1 {
+			// the primary copy responds late, whatever its context
+			time.Sleep(100 * time.Millisecond)
+			return &http.Response{StatusCode: http.StatusOK, Body: loser}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"copy":"hedge"}`))}, nil
+	})}
+
+	data, err := APIGatewayWithContext(context.Background(), nil, "https://api.example.com/items", "eu-west-1",
+		http.MethodGet, testCreds, WithHTTPClient(client), WithHedging(10*time.Millisecond))
+	if err != nil || string(data) != `{"copy":"hedge"}` {
+		t.Fatalf("got %s, %v, want the response of the hedge", data, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !loser.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("the body of the losing copy was not closed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHedgingIdempotentOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		opts     []Option
+		requests int32
+	}{
+		{"GET", http.MethodGet, nil, 2},
+		{"PUT", http.MethodPut, nil, 2},
+		{"POST", http.MethodPost, nil, 1},
+		{"PATCH", http.MethodPatch, nil, 1},
+		{"POST with an idempotency key", http.MethodPost, []Option{WithIdempotencyKey("")}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				requests.Add(1)
+				select {
+				case <-time.After(50 * time.Millisecond):
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+			})}
+
+			opts := append([]Option{WithHTTPClient(client), WithHedging(time.Millisecond)}, tt.opts...)
+			if _, err := APIGatewayWithContext(context.Background(), []byte(`{}`), "https://api.example.com/items",
+				"eu-west-1", tt.method, testCreds, opts...); err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("expected %d requests, got %d", tt.requests, got)
+			}
+		})
+	}
+}
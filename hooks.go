@@ -0,0 +1,61 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Hooks run around every attempt of a request, e.g. to log it, record metrics or add headers. Any of them may be nil.
+type Hooks struct {
+	// BeforeSign runs once the request is built, before it's signed: headers set here are part of the signature. The
+	// body must not be changed.
+	BeforeSign func(ctx context.Context, req *http.Request) error
+	// AfterSign runs once the request is signed, right before it's sent. Changing a signed header here gets the
+	// request rejected.
+	AfterSign func(ctx context.Context, req *http.Request) error
+	// AfterResponse runs once the attempt completes, with either its response (whose body must not be read) or the
+	// transport error, and how long the round trip took
+	AfterResponse func(ctx context.Context, req *http.Request, response *http.Response, err error, latency time.Duration)
+}
+
+// WithHooks runs hooks around every attempt. Hooks add up: those given to NewClient run before the per-call ones,
+// and a hook returning an error aborts the attempt with it.
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks)
+	}
+}
+
+func (o *options) beforeSign(ctx context.Context, req *http.Request) error {
+	for _, hooks := range o.hooks {
+		if hooks.BeforeSign == nil {
+			continue
+		}
+		if err := hooks.BeforeSign(ctx, req); err != nil {
+			return fmt.Errorf("before sign hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (o *options) afterSign(ctx context.Context, req *http.Request) error {
+	for _, hooks := range o.hooks {
+		if hooks.AfterSign == nil {
+			continue
+		}
+		if err := hooks.AfterSign(ctx, req); err != nil {
+			return fmt.Errorf("after sign hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (o *options) afterResponse(ctx context.Context, req *http.Request, response *http.Response, err error, latency time.Duration) {
+	for _, hooks := range o.hooks {
+		if hooks.AfterResponse != nil {
+			hooks.AfterResponse(ctx, req, response, err, latency)
+		}
+	}
+}
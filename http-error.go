@@ -0,0 +1,62 @@
+package iamsigned
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is returned when the server answers with a non-successful status code. It carries what AWS puts there
+// to diagnose the failure: the headers (x-amzn-RequestId, x-amzn-ErrorType...) and the beginning of the body.
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	// Body holds at most the first 64KB of the (decompressed) response body
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "received status code %v", e.StatusCode)
+	if id := e.RequestID(); id != "" {
+		fmt.Fprintf(&b, " (request id %s)", id)
+	}
+	if body := strings.TrimSpace(string(e.Body)); body != "" {
+		fmt.Fprintf(&b, ": %s", snippet([]byte(body)))
+	}
+	return b.String()
+}
+
+// RequestID returns the AWS request id of the response, if any
+func (e *HTTPError) RequestID() string {
+	return requestID(e.Header)
+}
+
+// Location returns the URL a redirect response points to, if any
+func (e *HTTPError) Location() string {
+	return e.Header.Get("Location")
+}
+
+// requestID returns the AWS request id found in response headers
+func requestID(header http.Header) string {
+	return firstNonEmpty(header.Get("X-Amzn-Requestid"), header.Get("X-Amz-Request-Id"), header.Get("X-Amz-Apigw-Id"))
+}
+
+// ErrorType returns the short error type AWS reports in the X-Amzn-ErrorType header (e.g. "UnauthorizedException")
+func (e *HTTPError) ErrorType() string {
+	return errorCodeFromType(e.Header.Get("X-Amzn-ErrorType"))
+}
+
+// newHTTPError reads the bounded body of a non-successful response, and closes it
+func (o *options) newHTTPError(response *http.Response) *HTTPError {
+	httpErr := &HTTPError{StatusCode: response.StatusCode, Header: response.Header.Clone()}
+	body, err := o.decodeBody(response)
+	if err != nil {
+		return httpErr
+	}
+	defer body.Close()
+	httpErr.Body, _ = io.ReadAll(io.LimitReader(body, maxErrorBodyBytes))
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBodyBytes))
+	return httpErr
+}
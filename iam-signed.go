@@ -7,121 +7,461 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
-	"golang.org/x/net/context/ctxhttp"
 )
 
 type (
-	graphqlError struct {
-		Locations []graphqlErrorLocation `json:"locations"`
-		Message   string                 `json:"message"`
-	}
-
-	graphqlErrorLocation struct {
-		Column int `json:"column"`
-		Line   int `json:"line"`
-	}
-
 	graphqlResponse struct {
 		Data   json.RawMessage `json:"data"`
-		Errors []graphqlError  `json:"errors"`
+		Errors json.RawMessage `json:"errors"`
 	}
 )
 
+// AWSService is the name a service is signed for. Any SigV4 service works, not only the constants below, e.g.
+// AWSService("events") for EventBridge.
 type AWSService string
 
 const (
 	AppSyncService    AWSService = "appsync"
 	APIGatewayService AWSService = "execute-api"
+	STSService        AWSService = "sts"
+	APSService        AWSService = "aps"
+	LambdaService     AWSService = "lambda"
+	// OpenSearchService signs for OpenSearch Service domains, and OpenSearchServerlessService for serverless
+	// collections
+	OpenSearchService           AWSService = "es"
+	OpenSearchServerlessService AWSService = "aoss"
+	NeptuneService              AWSService = "neptune-db"
+	// BedrockService signs for the Bedrock APIs, bedrock-runtime included
+	BedrockService AWSService = "bedrock"
+	// SageMakerService signs for the SageMaker APIs, the runtime included
+	SageMakerService AWSService = "sagemaker"
+	// IoTDeviceGatewayService signs for the AWS IoT Core data endpoints
+	IoTDeviceGatewayService AWSService = "iotdevicegateway"
 )
 
+// SupportedServices lists every AWSService known to the package
+func SupportedServices() []AWSService {
+	return []AWSService{
+		AppSyncService,
+		APIGatewayService,
+		STSService,
+		APSService,
+		LambdaService,
+		OpenSearchService,
+		OpenSearchServerlessService,
+		NeptuneService,
+		BedrockService,
+		SageMakerService,
+		IoTDeviceGatewayService,
+	}
+}
+
+// String returns the name used to sign requests for the service
+func (s AWSService) String() string {
+	return string(s)
+}
+
+// ParseService converts a signing name (e.g. "appsync") into one of the supported AWSService values
+func ParseService(name string) (AWSService, error) {
+	for _, s := range SupportedServices() {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported service '%s'", name)
+}
+
 // AppSync signs and send a request to appsync. It also parse the response and looks for graphql errors
-func AppSync(payload []byte, endpoint, region string, creds *credentials.Credentials) ([]byte, error) {
-	return AppSyncWithContext(context.Background(), payload, endpoint, region, creds)
+func AppSync(payload []byte, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	return AppSyncWithContext(context.Background(), payload, endpoint, region, creds, opts...)
 }
 
 // AppSyncWithContext does the same as AppSyncDeliver, with a context.Context object
-func AppSyncWithContext(ctx context.Context, payload []byte, endpoint, region string, creds *credentials.Credentials) ([]byte, error) {
-	body, err := deliverWithContext(ctx, payload, AppSyncService, endpoint, region, http.MethodPost, creds)
-	if err != nil {
-		return nil, err
-	}
-	return ParseGraphQLResponse(body)
+func AppSyncWithContext(ctx context.Context, payload []byte, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	ctx, end := o.startCall(ctx, AppSyncService, endpoint, region, http.MethodPost)
+	data, err := appSync(ctx, payload, endpoint, region, creds, o)
+	end(err)
+	return data, o.wrapError(err)
 }
 
-// APIGatewayWithContext does the same as APIGatewayDeliver, with a context.Context object
-func APIGatewayWithContext(ctx context.Context, payload []byte, endpoint, region, method string, creds *credentials.Credentials) ([]byte, error) {
-	body, err := deliverWithContext(ctx, payload, APIGatewayService, endpoint, region, method, creds)
+// appSync delivers the request, and returns the data of the GraphQL response
+func appSync(ctx context.Context, payload []byte, endpoint, region string, creds *credentials.Credentials, o *options) ([]byte, error) {
+	o.buffer = true
+	o.expectJSON = true
+	body, err := deliverWithContext(ctx, payload, AppSyncService, endpoint, region, http.MethodPost, creds, o)
 	if err != nil {
 		return nil, err
 	}
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(body); err != nil {
+	data, err := ParseGraphQLResponse(body)
+	if err != nil {
+		return data, err
+	}
+	if err := o.validateData(data); err != nil {
 		return nil, err
 	}
-	return buf.Bytes(), nil
+	return data, nil
+}
+
+// AppSyncStream signs and sends a request to appsync, and returns the response body once the status code is checked,
+// without buffering it. The GraphQL response is not parsed, so the caller must look for GraphQL errors in the body
+// themselves, and close it.
+func AppSyncStream(ctx context.Context, payload []byte, endpoint, region string, creds *credentials.Credentials, opts ...Option) (io.ReadCloser, error) {
+	o := newOptions(opts)
+	o.expectJSON = true
+	body, err := deliverWithContext(ctx, payload, AppSyncService, endpoint, region, http.MethodPost, creds, o)
+	return body, o.wrapError(err)
+}
+
+// APIGatewayWithContext does the same as APIGatewayDeliver, with a context.Context object
+func APIGatewayWithContext(ctx context.Context, payload []byte, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	return deliverBytes(ctx, payload, APIGatewayService, endpoint, region, method, creds, newOptions(opts))
 }
 
 // APIGateway signs and sends a request to API Gateway
-func APIGateway(payload []byte, endpoint, region, method string, creds *credentials.Credentials) ([]byte, error) {
-	return APIGatewayWithContext(context.Background(), payload, endpoint, region, method, creds)
+func APIGateway(payload []byte, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	return APIGatewayWithContext(context.Background(), payload, endpoint, region, method, creds, opts...)
 }
 
 // ParseGraphQLResponse attempts to read the response, and extract grpahql-formatted errors
 func ParseGraphQLResponse(body io.ReadCloser) (json.RawMessage, error) {
 
 	var parsed graphqlResponse
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(body); err != nil {
+	data, err := readAll(body)
+	if err != nil {
 		return []byte{}, fmt.Errorf("could not read buffer: %w", err)
 	}
-	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
-		newStr := buf.String()
-		return []byte{}, fmt.Errorf("could not parse response '%s': %w", newStr, err)
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return []byte{}, fmt.Errorf("could not parse response, expected JSON: '%s': %w", snippet(data), err)
+	}
+
+	errs, err := newGraphQLErrors(parsed.Errors)
+	return parsed.Data, errs.orError(err)
+}
+
+// AppSyncExec signs and sends a request to appsync, and only reports whether it succeeded: the data of the response
+// is skipped instead of being kept in memory, which is cheaper for large payloads the caller doesn't need
+func AppSyncExec(ctx context.Context, payload []byte, endpoint, region string, creds *credentials.Credentials, opts ...Option) error {
+	o := newOptions(opts)
+	o.expectJSON = true
+	ctx, end := o.startCall(ctx, AppSyncService, endpoint, region, http.MethodPost)
+	body, err := deliverWithContext(ctx, payload, AppSyncService, endpoint, region, http.MethodPost, creds, o)
+	if err == nil {
+		err = CheckGraphQLResponse(body)
+		body.Close()
+	}
+	end(err)
+	return o.wrapError(err)
+}
+
+// CheckGraphQLResponse streams a GraphQL response looking for graphql-formatted errors, without capturing its data
+func CheckGraphQLResponse(body io.Reader) error {
+	decoder := json.NewDecoder(body)
+	if t, err := decoder.Token(); err != nil || t != json.Delim('{') {
+		return fmt.Errorf("could not parse response, expected a JSON object")
 	}
 
-	if len(parsed.Errors) > 0 {
-		errStr := fmt.Sprintf("GraphQL returned %v error(s)", len(parsed.Errors))
-		for _, err := range parsed.Errors {
-			errStr += fmt.Sprintf("\n %+v: %s", err.Locations, err.Message)
+	var rawErrors json.RawMessage
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("could not parse response: %w", err)
+		}
+		if key == "errors" {
+			if err := decoder.Decode(&rawErrors); err != nil {
+				return fmt.Errorf("could not parse errors: %w", err)
+			}
+			continue
+		}
+		if err := skipJSONValue(decoder); err != nil {
+			return fmt.Errorf("could not parse response: %w", err)
 		}
-		return parsed.Data, fmt.Errorf(errStr)
 	}
-	return parsed.Data, nil
+
+	errs, err := newGraphQLErrors(rawErrors)
+	return errs.orError(err)
 }
 
-func deliver(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials) (io.ReadCloser, error) {
-	return deliverWithContext(context.Background(), payload, service, endpoint, region, method, creds)
+// skipJSONValue consumes the next value of the decoder token by token, so it's never held in memory as a whole
+func skipJSONValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
 }
 
-func deliverWithContext(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials) (io.ReadCloser, error) {
+// Deliver is the low-level primitive the other helpers are built on: it signs and sends a request to any SigV4
+// service, checks the status code, and returns the response body. The request is bound to ctx, and the caller must
+// close the body.
+func Deliver(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) (io.ReadCloser, error) {
+	o := newOptions(opts)
+	body, err := deliverWithContext(ctx, payload, service, endpoint, region, method, creds, o)
+	return body, o.wrapError(err)
+}
 
-	// Create http request
-	req, err := http.NewRequest(method, endpoint, bytes.NewBuffer(payload))
+func deliverWithContext(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (io.ReadCloser, error) {
+	ctx, end := o.startCall(ctx, service, endpoint, region, method)
+	defer o.recordLatency(time.Now())
+	if o.body == nil {
+		o.logPayload(ctx, "request payload", payload)
+	}
+	payload, err := o.compressPayload(payload)
 	if err != nil {
-		return nil, fmt.Errorf("could not create request: %w", err)
+		end(err)
+		return nil, err
+	}
+	o.setPayloadHash(payload)
+	ctx, cancel := o.withTimeout(ctx)
+	body, err := o.cached(service, endpoint, region, method, creds, payload, func() (io.ReadCloser, error) {
+		return o.deduplicated(service, endpoint, region, method, creds, payload, func() (io.ReadCloser, error) {
+			return deliverFailover(ctx, payload, service, endpoint, region, method, creds, o)
+		})
+	})
+	end(err)
+	if err != nil || o.buffer {
+		cancel()
+		return body, err
+	}
+	return &cancelOnClose{ReadCloser: body, cancel: cancel}, nil
+}
+
+// deliverAttempts sends the request, retrying it as configured
+func deliverAttempts(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (io.ReadCloser, error) {
+	endpoint, region, creds, err := o.prepare(endpoint, region, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	skewCorrected := false
+	for attempt := 1; ; attempt++ {
+		o.recordAttempt(attempt)
+		if creds != nil && o.signer == nil {
+			if err := refreshCredentials(ctx, creds); err != nil {
+				return nil, err
+			}
+		}
+		response, err := send(ctx, payload, service, endpoint, region, method, creds, o)
+		if err == nil && !skewCorrected && o.resendable() && o.correctClockSkew(response) {
+			// the request is sent once more, signed with the server time, without counting as an attempt
+			skewCorrected = true
+			discardBody(response)
+			attempt--
+			continue
+		}
+		if attempt < o.maxAttempts() && o.resendable() && o.shouldRetry(ctx, response, err) {
+			var retryAfter time.Duration
+			if response != nil {
+				retryAfter = parseRetryAfter(response.Header, time.Now())
+				discardBody(response)
+			}
+			if err := o.backoff(ctx, attempt, retryAfter, response, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := o.readResponse(response)
+		if err != nil || !o.buffer {
+			return body, err
+		}
+		data, err := o.readBody(body)
+		if err == nil {
+			o.logPayload(ctx, "response payload", data)
+			return newBufferedBody(data), nil
+		}
+		if attempt < o.maxAttempts() && o.resendable() && o.retryableBodyError(err) {
+			if err := o.backoff(ctx, attempt, 0, response, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, err
+	}
+}
+
+// DoSigned signs and sends a request to any SigV4 service, checks the status code, and returns the whole response body
+func DoSigned(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	return deliverBytes(ctx, payload, service, endpoint, region, method, creds, newOptions(opts))
+}
+
+// deliverBytes delivers the request, and returns the whole response body
+func deliverBytes(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) ([]byte, error) {
+	o.buffer = true
+	body, err := deliverWithContext(ctx, payload, service, endpoint, region, method, creds, o)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	defer body.Close()
+	data, err := readAll(body)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	return data, nil
+}
+
+// prepare validates the options, and resolves the endpoint, region and credentials to sign with
+func (o *options) prepare(endpoint, region string, creds *credentials.Credentials) (string, string, *credentials.Credentials, error) {
+	if o.err != nil {
+		return "", "", nil, o.err
+	}
+	if o.signer == nil {
+		var err error
+		if creds, err = o.resolveCredentials(creds); err != nil {
+			return "", "", nil, err
+		}
+	}
+	endpoint, err := o.endpoint(endpoint)
+	if err != nil {
+		return "", "", nil, err
+	}
+	region, err = o.region(endpoint, region)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if o.clock == nil {
+		o.clock = hostClock(endpoint)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return endpoint, region, creds, nil
+}
 
-	// Sign the request
-	signer := v4.NewSigner(creds)
-	_, err = signer.Sign(req, bytes.NewReader(payload), string(service), region, time.Now())
+// send builds, signs and sends a single attempt of the request. The request is signed again on every attempt, as the
+// signature embeds its timestamp.
+func send(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (*http.Response, error) {
+	if err := o.admit(endpoint); err != nil {
+		return nil, err
+	}
+	if o.hedges(method) {
+		return sendHedged(ctx, payload, service, endpoint, region, method, creds, o)
+	}
+	if err := o.waitRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	req, err := newSignedRequest(ctx, payload, service, endpoint, region, method, creds, o)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign the request: %w", err)
+		return nil, err
 	}
 
 	// Fire !
-	response, err := ctxhttp.Do(ctx, nil, req)
+	client, err := o.sendingClient(service, region, creds)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	response, err := client.Do(req.WithContext(o.traceContext(ctx)))
+	o.afterResponse(ctx, req, response, err, time.Since(start))
+	o.recordOutcome(ctx, endpoint, response, err)
+	if err != nil {
+		return nil, &transportError{err: err}
+	}
+	o.recordResponse(response)
+	return response, nil
+}
+
+// newSignedRequest builds the request and signs it
+func newSignedRequest(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (*http.Request, error) {
+	if o.body != nil {
+		return newStreamingRequest(ctx, service, endpoint, region, method, creds, o)
+	}
+
+	// Create http request
+	// a single reader is hashed by the signer, rewound, then sent
+	body := bytes.NewReader(payload)
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", o.requestContentType())
+	o.applyHeaders(req)
+	o.injectTrace(ctx, req)
+	if o.signContentLength {
+		req.Header.Set("Content-Length", strconv.Itoa(len(payload)))
+	}
+
+	if err := signRequest(ctx, req, body, service, region, creds, o); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// signRequest signs req in place. body is the request body, hashed then rewound: it may only be nil for empty bodies,
+// or when the payload hash is set in the X-Amz-Content-Sha256 header.
+func signRequest(ctx context.Context, req *http.Request, body io.ReadSeeker, service AWSService, region string, creds *credentials.Credentials, o *options) error {
+	if err := o.beforeSign(ctx, req); err != nil {
+		return err
+	}
+	var err error
+	if o.signer != nil {
+		var hash string
+		if hash, err = payloadHash(req, body); err == nil {
+			err = o.signer.SignRequest(ctx, req, hash, string(service), region, o.now())
+		}
+	} else if o.algorithm == SigV4A {
+		var hash string
+		if hash, err = payloadHash(req, body); err == nil {
+			var debug SigningDebug
+			if debug, err = signV4A(ctx, req, hash, string(service), region, creds, o.now()); err == nil {
+				o.reportSigning(ctx, debug)
+			}
+		}
+	} else {
+		_, err = o.v4Signer(ctx, creds).Sign(req, body, string(service), region, o.now())
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not send request: %w", err)
+		return &signingError{err: err}
+	}
+	o.applyAuthorizationHeader(req)
+	return o.afterSign(ctx, req)
+}
+
+// readResponse checks the status code of the response, and returns its decoded body
+func (o *options) readResponse(response *http.Response) (io.ReadCloser, error) {
+	if response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		return nil, ErrNotModified
 	}
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("received status code %v", response.StatusCode)
+	if !o.successful(response.StatusCode) {
+		httpErr := o.newHTTPError(response)
+		if o.parseErrorEnvelope {
+			if apiErr := parseAPIError(httpErr); apiErr != nil {
+				return nil, apiErr
+			}
+		}
+		return nil, httpErr
 	}
 
-	return response.Body, nil
+	body, err := o.decodeBody(response)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.checkContentType(response.Header, body); err != nil {
+		body.Close()
+		return nil, err
+	}
+	return body, nil
+}
+
+// discardBody drains and closes the body of a response that won't be used, so its connection can be reused
+func discardBody(response *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	response.Body.Close()
 }
@@ -0,0 +1,168 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckGraphQLResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		graphQL  bool
+		parseErr bool
+	}{
+		{"data", `{"data":{"createPost":{"id":"1","tags":["a","b"]}}}`, false, false},
+		{"errors after data", `{"data":null,"errors":[{"message":"Unauthorized"}]}`, true, false},
+		{"errors before data", `{"errors":[{"message":"Unauthorized"}],"data":{"createPost":null}}`, true, false},
+		{"not an object", `[]`, false, true},
+		{"truncated", `{"data":{"createPost":`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckGraphQLResponse(strings.NewReader(tt.body))
+			if graphQL := errors.Is(err, ErrGraphQL); graphQL != tt.graphQL {
+				t.Errorf("got error %v, want a GraphQL error: %v", err, tt.graphQL)
+			}
+			if parseErr := err != nil && !tt.graphQL; parseErr != tt.parseErr {
+				t.Errorf("got error %v, want a parse error: %v", err, tt.parseErr)
+			}
+		})
+	}
+}
+
+// largeGraphQLResponse is a successful response holding n items
+func largeGraphQLResponse(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"data":{"listPosts":{"items":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{"id":"%d","title":"post %d","body":"%s"}`, i, i, strings.Repeat("x", 200))
+	}
+	buf.WriteString(`]}}}`)
+	return buf.Bytes()
+}
+
+func BenchmarkCheckGraphQLResponse(b *testing.B) {
+	body := largeGraphQLResponse(1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		if err := CheckGraphQLResponse(bytes.NewReader(body)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseGraphQLResponse(b *testing.B) {
+	body := largeGraphQLResponse(1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseGraphQLResponse(io.NopCloser(bytes.NewReader(body))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDeliverPayload sends a 1MB payload, which is hashed then sent from a single reader: the bytes allocated
+// per operation should stay well under the payload size
+func BenchmarkDeliverPayload(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "eu-west-1", testCreds)
+	payload := bytes.Repeat([]byte("x"), 1<<20)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.APIGateway(ctx, payload, http.MethodPost); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDeliverHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		err  error
+	}{
+		{"deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 50*time.Millisecond)
+		}, context.DeadlineExceeded},
+		{"cancelled", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			start := time.Now()
+			body, err := Deliver(ctx, nil, APIGatewayService, server.URL, "eu-west-1", http.MethodGet, testCreds)
+			if err == nil {
+				body.Close()
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("the request went on for %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestParseService(t *testing.T) {
+	for _, service := range SupportedServices() {
+		if parsed, err := ParseService(service.String()); err != nil || parsed != service {
+			t.Errorf("ParseService(%q) = %q, %v", service, parsed, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		want    AWSService
+		wantErr bool
+	}{
+		{"appsync", AppSyncService, false},
+		{"execute-api", APIGatewayService, false},
+		{"aoss", OpenSearchServerlessService, false},
+		{"AppSync", "", true},
+		{"", "", true},
+		{"s4", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseService(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseService(%q) = %q, %v", tt.name, got, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Package iamsignedotel records iamsigned calls as OpenTelemetry spans, and propagates the trace context to the
+// called services.
+//
+// Only programs importing this package link OpenTelemetry.
+package iamsignedotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aherve/iamsigned"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/aherve/iamsigned"
+
+// Tracer is an iamsigned.Tracer creating a client span for every call
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// Option customizes a Tracer
+type Option func(*Tracer)
+
+// WithTracerProvider creates spans with provider instead of the global one
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithPropagator injects the trace context with propagator instead of the global one
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *Tracer) {
+		t.propagator = propagator
+	}
+}
+
+// NewTracer creates a Tracer, using the global tracer provider and propagator unless told otherwise
+func NewTracer(opts ...Option) *Tracer {
+	t := &Tracer{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.tracer == nil {
+		t.tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+	if t.propagator == nil {
+		t.propagator = otel.GetTextMapPropagator()
+	}
+	return t
+}
+
+// WithTracing records calls as spans. Given to iamsigned.NewClient, it applies to every call of the client:
+//
+//	client := iamsigned.NewClient(endpoint, region, creds, iamsignedotel.WithTracing())
+func WithTracing(opts ...Option) iamsigned.Option {
+	return iamsigned.WithTracer(NewTracer(opts...))
+}
+
+// StartCall starts a client span for the call
+func (t *Tracer) StartCall(ctx context.Context, call iamsigned.Call) (context.Context, func(iamsigned.CallResult)) {
+	ctx, span := t.tracer.Start(ctx, fmt.Sprintf("%s %s", call.Service, call.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "aws-api"),
+			attribute.String("aws.service", call.Service.String()),
+			attribute.String("aws.region", call.Region),
+			attribute.String("http.request.method", call.Method),
+			attribute.String("url.full", call.Endpoint),
+		),
+	)
+	return ctx, func(result iamsigned.CallResult) {
+		if result.StatusCode != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+		}
+		span.SetAttributes(attribute.Int("iamsigned.attempts", result.Attempts))
+		if result.GraphQLErrors > 0 {
+			span.SetAttributes(attribute.Int("graphql.errors.count", result.GraphQLErrors))
+		}
+		if result.Err != nil {
+			span.RecordError(result.Err)
+			span.SetStatus(codes.Error, result.Err.Error())
+		}
+		span.End()
+	}
+}
+
+// Inject adds the trace context of ctx to the request headers
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
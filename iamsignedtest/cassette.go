@@ -0,0 +1,272 @@
+// Package iamsignedtest provides helpers to test code built on top of iamsigned without calling AWS.
+//
+// It lives in its own package so that production builds don't pull it in.
+package iamsignedtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+type (
+	// Interaction is a single recorded request/response pair
+	Interaction struct {
+		Request  RecordedRequest  `json:"request"`
+		Response RecordedResponse `json:"response"`
+	}
+
+	// RecordedRequest identifies the request that produced a recorded response. Secrets are scrubbed from its
+	// headers, and from the query of its URL.
+	RecordedRequest struct {
+		Method string      `json:"method"`
+		URL    string      `json:"url"`
+		Header http.Header `json:"header,omitempty"`
+		Body   string      `json:"body,omitempty"`
+	}
+
+	// RecordedResponse holds everything needed to replay a response
+	RecordedResponse struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body"`
+	}
+)
+
+// scrubbedHeaders carry credentials, and are never written to a cassette
+var scrubbedHeaders = []string{"Authorization", "X-Amz-Security-Token", "X-Api-Key", "Cookie", "Set-Cookie"}
+
+// scrubbedParams carry the signature of presigned URLs, and are never written to a cassette
+var scrubbedParams = []string{"X-Amz-Signature", "X-Amz-Credential", "X-Amz-Security-Token"}
+
+const scrubbed = "REDACTED"
+
+// Recorder is an http.RoundTripper that forwards requests to a real transport, and records every request and response
+// pair, written to a golden file by Save or Close so it can be replayed later with a Replayer. The signature, session
+// token and other credentials are scrubbed before anything is written.
+type Recorder struct {
+	path         string
+	next         http.RoundTripper
+	scrubbers    []func(*Interaction)
+	scrubbed     []string
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// RecorderOption customizes a Recorder
+type RecorderOption func(*Recorder)
+
+// WithScrubbedHeaders also scrubs the given request and response headers
+func WithScrubbedHeaders(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.scrubbed = append(r.scrubbed, names...)
+	}
+}
+
+// WithScrubber runs scrub on every interaction before it's written, e.g. to hide personal data in bodies
+func WithScrubber(scrub func(*Interaction)) RecorderOption {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrub)
+	}
+}
+
+// NewRecorder creates a Recorder writing to path. A nil transport falls back to http.DefaultTransport
+func NewRecorder(path string, transport http.RoundTripper, opts ...RecorderOption) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{path: path, next: transport, scrubbed: append([]string(nil), scrubbedHeaders...)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// RoundTrip sends the request and records the response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    scrubURL(req.URL),
+			Header: r.scrubHeader(req.Header),
+			Body:   string(reqBody),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubHeader(resp.Header),
+			Body:       string(body),
+		},
+	}
+	for _, scrub := range r.scrubbers {
+		scrub(&interaction)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+	return resp, nil
+}
+
+// Save writes the interactions recorded so far to the golden file
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return writeCassette(r.path, r.interactions)
+}
+
+// Close saves the recorded interactions, see Save
+func (r *Recorder) Close() error {
+	return r.Save()
+}
+
+// scrubHeader returns a copy of header without its secrets
+func (r *Recorder) scrubHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range r.scrubbed {
+		if _, ok := header[http.CanonicalHeaderKey(name)]; ok {
+			header.Set(name, scrubbed)
+		}
+	}
+	return header
+}
+
+// Replayer is an http.RoundTripper serving responses previously captured by a Recorder, in the order they were
+// recorded. Each request must match the method and URL of the next recorded interaction, and its body too with
+// MatchBody. Requests are not checked for a valid signature, so replays work with any credentials, e.g. Credentials().
+type Replayer struct {
+	matchBody    bool
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// ReplayerOption customizes a Replayer
+type ReplayerOption func(*Replayer)
+
+// MatchBody also requires the request body to match the recorded one
+func MatchBody() ReplayerOption {
+	return func(r *Replayer) {
+		r.matchBody = true
+	}
+}
+
+// NewReplayer loads the golden file at path
+func NewReplayer(path string, opts ...ReplayerOption) (*Replayer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cassette: %w", err)
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(content, &interactions); err != nil {
+		return nil, fmt.Errorf("could not parse cassette '%s': %w", path, err)
+	}
+	r := &Replayer{interactions: interactions}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// RoundTrip replays the next recorded response
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.interactions) == 0 {
+		return nil, fmt.Errorf("no recorded interaction left for %s %s", req.Method, req.URL)
+	}
+	next := r.interactions[0]
+	if !strings.EqualFold(next.Request.Method, req.Method) || next.Request.URL != scrubURL(req.URL) {
+		return nil, fmt.Errorf("unexpected request %s %s, next recorded one is %s %s", req.Method, req.URL, next.Request.Method, next.Request.URL)
+	}
+	if r.matchBody && next.Request.Body != string(body) {
+		return nil, fmt.Errorf("unexpected body for %s %s: %s", req.Method, req.URL, body)
+	}
+	r.interactions = r.interactions[1:]
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", next.Response.StatusCode, http.StatusText(next.Response.StatusCode)),
+		StatusCode:    next.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        next.Response.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(next.Response.Body)),
+		ContentLength: int64(len(next.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Credentials returns static, fake credentials, to sign requests replayed by a Replayer without live credentials
+func Credentials() *credentials.Credentials {
+	return credentials.NewStaticCredentials("AKIDIAMSIGNEDTEST", "iamsignedtest", "")
+}
+
+// scrubURL returns u without the signature in its query. Other URLs are left as is.
+func scrubURL(u *url.URL) string {
+	query := u.Query()
+	found := false
+	for _, name := range scrubbedParams {
+		if query.Has(name) {
+			query.Set(name, scrubbed)
+			found = true
+		}
+	}
+	if !found {
+		return u.String()
+	}
+	scrubbedURL := *u
+	scrubbedURL.RawQuery = query.Encode()
+	return scrubbedURL.String()
+}
+
+func writeCassette(path string, interactions []Interaction) error {
+	content, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode cassette: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("could not write cassette: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package iamsignedtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderAndReplayer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"getUser":{"id":"1"}}}`))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	signed := func(signature string) string {
+		return server.URL + "/graphql?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=AKIDEXAMPLE%2F20240101" +
+			"&X-Amz-Security-Token=session&X-Amz-Signature=" + signature
+	}
+
+	recorder := NewRecorder(path, nil)
+	client := &http.Client{Transport: recorder}
+	for _, url := range []string{server.URL + "/graphql", signed("c0ffee")} {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("could not record %s: %v", url, err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the cassette was written before Close: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("could not save cassette: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read cassette: %v", err)
+	}
+	for _, secret := range []string{"c0ffee", "AKIDEXAMPLE", "session"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("the cassette holds %q", secret)
+		}
+	}
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("could not load cassette: %v", err)
+	}
+	client = &http.Client{Transport: replayer}
+	// a replayed request is signed again, with another signature
+	for _, url := range []string{server.URL + "/graphql", signed("decaf")} {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("could not replay %s: %v", url, err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(server.URL + "/graphql"); err == nil {
+		t.Error("an interaction was replayed twice")
+	}
+}
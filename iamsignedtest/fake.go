@@ -0,0 +1,127 @@
+package iamsignedtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/aherve/iamsigned"
+)
+
+var _ iamsigned.Sender = (*Fake)(nil)
+
+// FakeRequest is a call received by a Fake
+type FakeRequest struct {
+	Service iamsigned.AWSService
+	Method  string
+	Payload []byte
+}
+
+// Fake is an in-memory iamsigned.Sender: it records every call, and answers with canned responses in the order they
+// were queued. AppSync calls parse their canned body exactly like a real response, so GraphQL errors surface as
+// *iamsigned.GraphQLErrors. Options passed to calls are ignored.
+type Fake struct {
+	// Handler, when set, answers every call instead of the queued responses
+	Handler func(req FakeRequest) ([]byte, error)
+
+	mu        sync.Mutex
+	requests  []FakeRequest
+	responses []fakeResponse
+}
+
+type fakeResponse struct {
+	body []byte
+	err  error
+}
+
+// NewFake creates a Fake without any canned response
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Respond queues a response body
+func (f *Fake) Respond(body []byte) *Fake {
+	return f.enqueue(fakeResponse{body: body})
+}
+
+// RespondError queues a failure, e.g. an *iamsigned.HTTPError
+func (f *Fake) RespondError(err error) *Fake {
+	return f.enqueue(fakeResponse{err: err})
+}
+
+// RespondGraphQL queues a GraphQL response holding data, marshaled to JSON, and errs
+func (f *Fake) RespondGraphQL(data interface{}, errs ...iamsigned.GraphQLError) *Fake {
+	response := struct {
+		Data   interface{}              `json:"data"`
+		Errors []iamsigned.GraphQLError `json:"errors,omitempty"`
+	}{data, errs}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return f.enqueue(fakeResponse{err: fmt.Errorf("could not encode canned GraphQL response: %w", err)})
+	}
+	return f.Respond(body)
+}
+
+// Requests returns the calls received so far
+func (f *Fake) Requests() []FakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeRequest(nil), f.requests...)
+}
+
+// Do records the call, and returns the next canned response
+func (f *Fake) Do(ctx context.Context, payload []byte, service iamsigned.AWSService, method string, opts ...iamsigned.Option) ([]byte, error) {
+	return f.serve(FakeRequest{Service: service, Method: method, Payload: payload})
+}
+
+// AppSync records the call, and parses the next canned response as a GraphQL response
+func (f *Fake) AppSync(ctx context.Context, payload []byte, opts ...iamsigned.Option) (json.RawMessage, error) {
+	body, err := f.serve(FakeRequest{Service: iamsigned.AppSyncService, Method: http.MethodPost, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	return iamsigned.ParseGraphQLResponse(io.NopCloser(bytes.NewReader(body)))
+}
+
+// AppSyncQuery does the same as AppSync, with the marshaled request as payload
+func (f *Fake) AppSyncQuery(ctx context.Context, req iamsigned.GraphQLRequest, opts ...iamsigned.Option) (json.RawMessage, error) {
+	payload, err := req.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return f.AppSync(ctx, payload, opts...)
+}
+
+// APIGateway records the call, and returns the next canned response
+func (f *Fake) APIGateway(ctx context.Context, payload []byte, method string, opts ...iamsigned.Option) ([]byte, error) {
+	return f.serve(FakeRequest{Service: iamsigned.APIGatewayService, Method: method, Payload: payload})
+}
+
+func (f *Fake) enqueue(response fakeResponse) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, response)
+	return f
+}
+
+func (f *Fake) serve(req FakeRequest) ([]byte, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	handler := f.Handler
+	if handler != nil {
+		f.mu.Unlock()
+		return handler(req)
+	}
+	defer f.mu.Unlock()
+	if len(f.responses) == 0 {
+		return nil, errors.New("no canned response left")
+	}
+	next := f.responses[0]
+	f.responses = f.responses[1:]
+	return next.body, next.err
+}
@@ -0,0 +1,76 @@
+package iamsignedtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aherve/iamsigned"
+)
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestFakeWithTypedHelpers(t *testing.T) {
+	ctx := context.Background()
+	req := iamsigned.GraphQLRequest{Query: "query { getUser(id: \"1\") { id name } }"}
+	want := user{ID: "1", Name: "Ada"}
+
+	tests := []struct {
+		name string
+		fake *Fake
+		call func(fake *Fake) (user, error)
+	}{
+		{"AppSyncQueryAs", NewFake().RespondGraphQL(want), func(fake *Fake) (user, error) {
+			return iamsigned.AppSyncQueryAs[user](ctx, fake, req)
+		}},
+		{"AppSyncFieldAs", NewFake().RespondGraphQL(map[string]user{"getUser": want}), func(fake *Fake) (user, error) {
+			return iamsigned.AppSyncFieldAs[user](ctx, fake, req, "getUser")
+		}},
+		{"APIGatewayAs", NewFake().Respond([]byte(`{"id":"1","name":"Ada"}`)), func(fake *Fake) (user, error) {
+			return iamsigned.APIGatewayAs[user](ctx, fake, nil, http.MethodGet)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call(tt.fake)
+			if err != nil || got != want {
+				t.Errorf("got %+v (%v), want %+v", got, err, want)
+			}
+			if requests := tt.fake.Requests(); len(requests) != 1 {
+				t.Errorf("got %d requests, want 1", len(requests))
+			}
+		})
+	}
+
+	failure := errors.New("unreachable")
+	if _, err := iamsigned.APIGatewayAs[user](ctx, NewFake().RespondError(failure), nil, http.MethodGet); !errors.Is(err, failure) {
+		t.Errorf("got error %v, want the canned one", err)
+	}
+}
+
+func TestFakeWithPaginate(t *testing.T) {
+	fake := NewFake().
+		RespondGraphQL(map[string]interface{}{"listUsers": map[string]interface{}{
+			"items": []user{{ID: "1"}}, "nextToken": "page2",
+		}}).
+		RespondGraphQL(map[string]interface{}{"listUsers": map[string]interface{}{
+			"items": []user{{ID: "2"}}, "nextToken": nil,
+		}})
+	req := iamsigned.GraphQLRequest{Query: "query ($nextToken: String) { listUsers(nextToken: $nextToken) { items { id } nextToken } }"}
+
+	users, err := iamsigned.Paginate[user](context.Background(), fake, req, "listUsers.items", "listUsers.nextToken")
+	if err != nil {
+		t.Fatalf("could not paginate: %v", err)
+	}
+	if len(users) != 2 || users[0].ID != "1" || users[1].ID != "2" {
+		t.Errorf("got users %+v", users)
+	}
+	if requests := fake.Requests(); len(requests) != 2 || !strings.Contains(string(requests[1].Payload), `"nextToken":"page2"`) {
+		t.Errorf("the second page was not requested with the token: %+v", requests)
+	}
+}
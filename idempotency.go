@@ -0,0 +1,26 @@
+package iamsigned
+
+// IdempotencyKeyHeader is the header WithIdempotencyKey sets
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKey sends key in the Idempotency-Key header, signed, so a backend deduplicating on it (e.g. API Gateway
+// in front of a payment service) applies a mutation once however many times it's received. An empty key generates a
+// random UUID for every call, which also makes the option safe to give to NewClient.
+//
+// The key is the same for every attempt of a call: retries, hedged copies and failovers included. As repeating the
+// request is then safe, responses failing midway are retried too, as with WithRetryOnBodyError.
+func WithIdempotencyKey(key string) Option {
+	return func(o *options) {
+		value := key
+		if value == "" {
+			id, err := newID()
+			if err != nil {
+				o.err = err
+				return
+			}
+			value = id
+		}
+		o.setHeader(IdempotencyKeyHeader, value)
+		o.idempotent = true
+	}
+}
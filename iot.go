@@ -0,0 +1,25 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// IoTPublish publishes payload to an MQTT topic (e.g. "devices/42/commands") through the HTTPS data endpoint of AWS
+// IoT Core, e.g. https://xxx-ats.iot.eu-west-1.amazonaws.com, with a QoS of 0 or 1
+func IoTPublish(ctx context.Context, payload []byte, endpoint, topic string, qos int, region string, creds *credentials.Credentials, opts ...Option) error {
+	o := newOptions(opts)
+	if qos != 0 && qos != 1 {
+		return o.wrapError(fmt.Errorf("invalid QoS %d, must be 0 or 1", qos))
+	}
+	WithQueryParam("qos", strconv.Itoa(qos))(o)
+	// the topic is a single path segment: its slashes are escaped too
+	target := strings.TrimSuffix(endpoint, "/") + "/topics/" + escapePathSegment(topic)
+	_, err := deliverBytes(ctx, payload, IoTDeviceGatewayService, target, region, http.MethodPost, creds, o)
+	return err
+}
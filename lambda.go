@@ -0,0 +1,25 @@
+package iamsigned
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// LambdaURL signs and sends a request to a Lambda function URL using the AWS_IAM auth type, e.g.
+// https://<url-id>.lambda-url.<region>.on.aws/orders, and returns the response body. Any 2xx status is successful.
+// The payload hash is also sent in the X-Amz-Content-Sha256 header, which function URLs require behind CloudFront
+// origin access control.
+func LambdaURL(ctx context.Context, payload []byte, url, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	o.sendPayloadHash()
+	return deliverBytes(ctx, payload, LambdaService, url, region, method, creds, o)
+}
+
+// LambdaURLStream does the same as LambdaURL for functions using the RESPONSE_STREAM invoke mode, returning the
+// response while the function is still streaming it. The caller must close its body.
+func LambdaURLStream(ctx context.Context, payload []byte, url, region, method string, creds *credentials.Credentials, opts ...Option) (*StreamResponse, error) {
+	o := newOptions(opts)
+	o.sendPayloadHash()
+	return deliverStream(ctx, payload, LambdaService, url, region, method, creds, o)
+}
@@ -0,0 +1,104 @@
+package iamsigned
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// LogLevels sets the level each kind of log line is emitted at
+type LogLevels struct {
+	// Request is used for the start and completion of every call
+	Request slog.Level
+	// Retry is used for attempts that are retried
+	Retry slog.Level
+	// Failure is used for failed calls, including GraphQL errors
+	Failure slog.Level
+}
+
+// defaultLogLevels keeps successful calls out of the logs unless debugging
+var defaultLogLevels = LogLevels{Request: slog.LevelDebug, Retry: slog.LevelInfo, Failure: slog.LevelWarn}
+
+// WithLogLevels replaces the default levels (requests at Debug, retries at Info, failures at Warn)
+func WithLogLevels(levels LogLevels) Option {
+	return func(o *options) {
+		o.levels = &levels
+	}
+}
+
+// WithPayloadLogging also logs request and buffered response bodies, at Debug level. They may hold personal data or
+// secrets: this is meant for local debugging only.
+func WithPayloadLogging() Option {
+	return func(o *options) {
+		o.logPayloads = true
+	}
+}
+
+func (o *options) logLevels() LogLevels {
+	if o.levels == nil {
+		return defaultLogLevels
+	}
+	return *o.levels
+}
+
+// logRetry reports an attempt that is about to be retried
+func (o *options) logRetry(ctx context.Context, attempt int, response *http.Response, err error, delay time.Duration) {
+	attrs := []slog.Attr{slog.Int("attempt", attempt), slog.Duration("delay", delay)}
+	if response != nil {
+		attrs = append(attrs, slog.Int("status", response.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	o.log().LogAttrs(ctx, o.logLevels().Retry, "retrying request", attrs...)
+}
+
+// logFailover reports a call moving on to a fallback endpoint
+func (o *options) logFailover(ctx context.Context, fallback RegionalEndpoint, err error) {
+	o.log().LogAttrs(ctx, o.logLevels().Retry, "failing over", slog.String("endpoint", fallback.Endpoint),
+		slog.String("region", fallback.Region), slog.String("error", err.Error()))
+}
+
+// logPayload logs a request or response body, when payload logging is enabled
+func (o *options) logPayload(ctx context.Context, msg string, payload []byte) {
+	if o.logPayloads {
+		o.log().LogAttrs(ctx, slog.LevelDebug, msg, slog.String("payload", string(payload)))
+	}
+}
+
+// callLogger is the Tracer logging the start and completion of calls
+type callLogger struct {
+	logger *slog.Logger
+	levels LogLevels
+}
+
+func (l *callLogger) StartCall(ctx context.Context, call Call) (context.Context, func(CallResult)) {
+	attrs := []slog.Attr{
+		slog.String("service", call.Service.String()),
+		slog.String("method", call.Method),
+		slog.String("endpoint", call.Endpoint),
+		slog.String("region", call.Region),
+	}
+	l.logger.LogAttrs(ctx, l.levels.Request, "sending request", attrs...)
+	start := time.Now()
+
+	return ctx, func(result CallResult) {
+		attrs := append(attrs,
+			slog.Int("status", result.StatusCode),
+			slog.Int("attempts", result.Attempts),
+			slog.Duration("duration", time.Since(start)),
+		)
+		if result.Err == nil {
+			l.logger.LogAttrs(ctx, l.levels.Request, "request completed", attrs...)
+			return
+		}
+		if result.GraphQLErrors > 0 {
+			attrs = append(attrs, slog.Int("graphql_errors", result.GraphQLErrors))
+		}
+		attrs = append(attrs, slog.String("error", result.Err.Error()))
+		l.logger.LogAttrs(ctx, l.levels.Failure, "request failed", attrs...)
+	}
+}
+
+func (l *callLogger) Inject(context.Context, http.Header) {}
@@ -0,0 +1,50 @@
+package iamsigned
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruMap is a map holding at most maxEntries values, evicting the least recently used ones. It's safe for concurrent
+// use.
+type lruMap[K comparable, V any] struct {
+	maxEntries int
+	mu         sync.Mutex
+	entries    map[K]*list.Element
+	// order lists the entries from the most to the least recently used
+	order *list.List
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRUMap[K comparable, V any](maxEntries int) *lruMap[K, V] {
+	return &lruMap[K, V]{maxEntries: maxEntries, entries: make(map[K]*list.Element), order: list.New()}
+}
+
+// get returns the value of key, building it with create on first use
+func (m *lruMap[K, V]) get(key K, create func() V) V {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.order.MoveToFront(element)
+		return element.Value.(*lruEntry[K, V]).value
+	}
+	value := create()
+	m.entries[key] = m.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+	return value
+}
+
+// len returns the number of entries held
+func (m *lruMap[K, V]) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
@@ -0,0 +1,43 @@
+package iamsigned
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLRUMap(t *testing.T) {
+	m := newLRUMap[string, int](2)
+	created := 0
+	get := func(key string) int {
+		return m.get(key, func() int {
+			created++
+			return created
+		})
+	}
+
+	a := get("a")
+	get("b")
+	if get("a") != a {
+		t.Error("a was created again")
+	}
+	// c evicts b, the least recently used
+	get("c")
+	if get("a") != a || created != 3 {
+		t.Errorf("a was evicted, %d values created", created)
+	}
+	if get("b"); created != 4 || m.len() != 2 {
+		t.Errorf("got %d values created and %d entries, want b created again and 2 entries", created, m.len())
+	}
+}
+
+func TestHostClocksAreBounded(t *testing.T) {
+	for i := 0; i < 1500; i++ {
+		hostClock(fmt.Sprintf("https://api-%d.example.com/", i))
+	}
+	if n := hostClocks.len(); n > 1000 {
+		t.Errorf("%d host clocks are held", n)
+	}
+	if hostClock("https://api-1499.example.com/") != hostClock("https://api-1499.example.com/graphql") {
+		t.Error("calls to the same host don't share a clock")
+	}
+}
@@ -0,0 +1,85 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// FormFile is a file part of a multipart/form-data body
+type FormFile struct {
+	// Field is the name of the form field
+	Field string
+	// Name is the file name sent to the server
+	Name string
+	// ContentType defaults to application/octet-stream
+	ContentType string
+	Content     io.Reader
+}
+
+// MultipartPayload builds a multipart/form-data body holding the fields (sorted by name) then the files, and returns
+// it along with its Content-Type, which carries the random boundary
+func MultipartPayload(fields map[string]string, files ...FormFile) ([]byte, string, error) {
+	var payload bytes.Buffer
+	writer := multipart.NewWriter(&payload)
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writer.WriteField(name, fields[name]); err != nil {
+			return nil, "", fmt.Errorf("could not write form field '%s': %w", name, err)
+		}
+	}
+
+	for _, file := range files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(file.Field), escapeQuotes(file.Name)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not write form file '%s': %w", file.Name, err)
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return nil, "", fmt.Errorf("could not write form file '%s': %w", file.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("could not write multipart body: %w", err)
+	}
+	return payload.Bytes(), writer.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes a Content-Disposition parameter as mime/multipart does
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// APIGatewayMultipart signs and sends a multipart/form-data request (e.g. a file upload) to API Gateway. The body is
+// built in memory, so it can be hashed for the signature.
+func APIGatewayMultipart(ctx context.Context, fields map[string]string, files []FormFile, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	payload, contentType, err := MultipartPayload(fields, files...)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	o.contentType = contentType
+	return deliverBytes(ctx, payload, APIGatewayService, endpoint, region, method, creds, o)
+}
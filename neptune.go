@@ -0,0 +1,61 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// NeptuneGremlin runs a Gremlin query through the HTTP endpoint of a Neptune cluster using IAM authentication, e.g.
+// https://my-cluster.cluster-xxx.eu-west-1.neptune.amazonaws.com:8182, and returns the response as is
+func NeptuneGremlin(ctx context.Context, query, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	payload, err := json.Marshal(struct {
+		Gremlin string `json:"gremlin"`
+	}{query})
+	if err != nil {
+		return nil, o.wrapError(fmt.Errorf("could not encode query: %w", err))
+	}
+	return deliverBytes(ctx, payload, NeptuneService, neptuneURL(endpoint, "/gremlin"), region, http.MethodPost, creds, o)
+}
+
+// NeptuneSPARQL runs a SPARQL query, sent form-encoded, see NeptuneGremlin. Results are asked as
+// application/sparql-results+json, unless an Accept header is given (WithHeader).
+func NeptuneSPARQL(ctx context.Context, query, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	if o.header.Get("Accept") == "" {
+		o.setHeader("Accept", "application/sparql-results+json")
+	}
+	return neptuneForm(ctx, url.Values{"query": {query}}, "/sparql", endpoint, region, creds, o)
+}
+
+// NeptuneOpenCypher runs an openCypher query, sent form-encoded along with its parameters (nil for none), see
+// NeptuneGremlin
+func NeptuneOpenCypher(ctx context.Context, query string, parameters interface{}, endpoint, region string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	form := url.Values{"query": {query}}
+	if parameters != nil {
+		encoded, err := json.Marshal(parameters)
+		if err != nil {
+			return nil, o.wrapError(fmt.Errorf("could not encode parameters: %w", err))
+		}
+		form.Set("parameters", string(encoded))
+	}
+	return neptuneForm(ctx, form, "/openCypher", endpoint, region, creds, o)
+}
+
+// neptuneForm posts a form-encoded query to a Neptune endpoint
+func neptuneForm(ctx context.Context, form url.Values, path, endpoint, region string, creds *credentials.Credentials, o *options) ([]byte, error) {
+	o.contentType = "application/x-www-form-urlencoded"
+	return deliverBytes(ctx, []byte(form.Encode()), NeptuneService, neptuneURL(endpoint, path), region, http.MethodPost, creds, o)
+}
+
+// neptuneURL appends the path of a query language to the cluster endpoint
+func neptuneURL(endpoint, path string) string {
+	return strings.TrimSuffix(endpoint, "/") + path
+}
@@ -0,0 +1,69 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// ndjsonOperations take newline-delimited JSON bodies
+var ndjsonOperations = []string{"/_bulk", "/_msearch", "/_msearch/template"}
+
+// OpenSearch signs and sends a request to an OpenSearch Service domain. endpoint is the full URL of the operation,
+// path and query string included (e.g. https://search-xxx.eu-west-1.es.amazonaws.com/orders/_search?size=10), and
+// payload may be nil. Bodies of _bulk and _msearch operations are sent as NDJSON (see NDJSON), any other as JSON.
+func OpenSearch(ctx context.Context, payload []byte, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	o.openSearchContentType(endpoint)
+	return deliverBytes(ctx, payload, OpenSearchService, endpoint, region, method, creds, o)
+}
+
+// OpenSearchServerless does the same as OpenSearch against an OpenSearch Serverless collection (e.g.
+// https://xxx.eu-west-1.aoss.amazonaws.com/orders/_doc), also sending the X-Amz-Content-Sha256 header collections
+// require
+func OpenSearchServerless(ctx context.Context, payload []byte, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	o.openSearchContentType(endpoint)
+	o.sendPayloadHash()
+	return deliverBytes(ctx, payload, OpenSearchServerlessService, endpoint, region, method, creds, o)
+}
+
+// NDJSON encodes each line as JSON on its own line, as the _bulk and _msearch operations expect, e.g. alternating
+// actions and documents:
+//
+//	body, err := iamsigned.NDJSON(
+//		map[string]interface{}{"index": map[string]string{"_index": "orders", "_id": "1"}}, order1,
+//		map[string]interface{}{"delete": map[string]string{"_index": "orders", "_id": "2"}},
+//	)
+func NDJSON(lines ...interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for i, line := range lines {
+		if err := encoder.Encode(line); err != nil {
+			return nil, fmt.Errorf("could not encode line %d: %w", i, err)
+		}
+	}
+	return body.Bytes(), nil
+}
+
+// openSearchContentType picks the Content-Type of an operation, unless the caller set one
+func (o *options) openSearchContentType(endpoint string) {
+	if o.contentType != "" {
+		return
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return
+	}
+	for _, operation := range ndjsonOperations {
+		if strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), operation) {
+			o.contentType = "application/x-ndjson"
+			return
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package iamsigned
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"golang.org/x/sync/singleflight"
+)
+
+const authorizationHeader = "Authorization"
+
+// Option customizes how a single request is built, signed and sent
+type Option func(*options)
+
+type options struct {
+	authorizationHeader string
+	httpClient          *http.Client
+	signingTime         time.Time
+	decompressLimit     *int64
+	contentType         string
+	header              http.Header
+	endpointResolver    EndpointResolver
+	errorContext        map[string]string
+	signContentLength   bool
+	requireContentType  bool
+	expectJSON          bool
+	pinnedClient        *http.Client
+	dataValidator       func(json.RawMessage) error
+	attempts            int
+	retryPredicate      func(statusCode int, header http.Header) bool
+	retryClassifier     func(response *http.Response, err error) bool
+	retryBaseDelay      time.Duration
+	retryMaxDelay       time.Duration
+	info                *ResponseInfo
+	successStatus       func(code int) bool
+	normalizeEndpoint   bool
+	trailingSlash       TrailingSlash
+	parseErrorEnvelope  bool
+	regionResolver      func(endpoint string) (string, error)
+	query               url.Values
+	redact              bool
+	// body streams the request body instead of the payload, see DeliverReader
+	body      io.Reader
+	bodySent  bool
+	bodyStart int64
+	// buffer reads the whole response body within the retry loop
+	buffer          bool
+	retryBodyErrors bool
+	retryByteBudget int64
+	bytesRead       int64
+	// response is the last response received
+	response     *http.Response
+	logger       *slog.Logger
+	signer       Signer
+	v1Signer     *v4.Signer
+	algorithm    SigningAlgorithm
+	profile      string
+	tracers      []Tracer
+	hooks        []Hooks
+	levels       *LogLevels
+	logPayloads  bool
+	debugSigning bool
+	timeout      time.Duration
+	// defaultClient replaces http.DefaultClient, see NewClient. Its transport was built with defaultTransport.
+	defaultClient    *http.Client
+	defaultTransport transportSettings
+	transport        transportSettings
+	transportCache   *transportCache
+	concurrency      int
+	cache            *responseCache
+	noCache          bool
+	inflight         *singleflight.Group
+	limiter          RateLimiter
+	breaker          *circuitBreaker
+	fallbacks        []RegionalEndpoint
+	hedgeDelay       time.Duration
+	gzipRequest      bool
+	persisted        *persistedQueries
+	idempotent       bool
+	// noSkewCorrection disables the clock skew correction, see NoClockSkewCorrection
+	noSkewCorrection bool
+	// clock is the offset to the server clock, owned by the Client or shared per host
+	clock *clockOffset
+	// hashPayload sends the hash of the payload in X-Amz-Content-Sha256, see sendPayloadHash
+	hashPayload bool
+	// redirectHosts are the hosts redirects are followed to, see WithRedirects
+	redirectHosts map[string]bool
+	signingDebug  func(SigningDebug)
+	// tracing is set once the call is reported to the tracer
+	tracing bool
+	attempt int
+	// err is an invalid option, reported when the request is sent
+	err error
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	o.sanitizeHeaders()
+	return o
+}
+
+// WithAuthorizationHeader moves the computed SigV4 Authorization header to another header name (e.g. X-Authorization)
+// once the request is signed.
+//
+// The header is only renamed after the signature is computed, so this is only safe against gateways that read the
+// signature from that header and verify it without re-signing over the header name: a backend relying on the
+// standard Authorization header will reject the request.
+func WithAuthorizationHeader(name string) Option {
+	return func(o *options) {
+		o.authorizationHeader = name
+	}
+}
+
+// WithHTTPClient sends requests through the given client instead of http.DefaultClient (or the pooled transport of a
+// Client), e.g. to set timeouts, proxies or connection pool settings. Given to NewClient, it applies to every request
+// of the Client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithSigningTime signs the request as if it was sent at t, instead of the current time.
+//
+// The credential scope uses the UTC date of t, so a local time just before midnight may fall on the next (or previous)
+// day in the scope. AWS rejects signatures too far from its own clock, so this is mostly useful for pre-generated
+// signatures and tests.
+func WithSigningTime(t time.Time) Option {
+	return func(o *options) {
+		o.signingTime = t
+	}
+}
+
+// WithDataValidator runs validate on the data of successful AppSync responses before returning it, e.g. to check it
+// against a schema (see the schema subpackage). Responses holding GraphQL errors are not validated.
+func WithDataValidator(validate func(data json.RawMessage) error) Option {
+	return func(o *options) {
+		o.dataValidator = validate
+	}
+}
+
+// WithSignedContentLength sets the Content-Length header before signing, so it's part of the SignedHeaders, for the
+// strict SigV4 backends that require it
+func WithSignedContentLength() Option {
+	return func(o *options) {
+		o.signContentLength = true
+	}
+}
+
+// WithAcceptStatus decides which status codes are successful, instead of the 2xx range. Responses with any other
+// status fail with an *HTTPError.
+func WithAcceptStatus(accept func(code int) bool) Option {
+	return func(o *options) {
+		o.successStatus = accept
+	}
+}
+
+// now returns the time used to sign the request
+func (o *options) now() time.Time {
+	if !o.signingTime.IsZero() {
+		return o.signingTime.UTC()
+	}
+	return time.Now().Add(o.clock.offset()).UTC()
+}
+
+// client returns the HTTP client to send requests with
+func (o *options) client() (*http.Client, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	custom := o.transport != transportSettings{}
+	if o.pinnedClient != nil {
+		if o.httpClient != nil {
+			return nil, errors.New("public key pinning can't be combined with a custom HTTP client")
+		}
+		if custom {
+			return nil, errors.New("public key pinning can't be combined with transport options")
+		}
+		return o.pinnedClient, nil
+	}
+	if o.httpClient != nil {
+		if custom {
+			return nil, errors.New("transport options can't be combined with a custom HTTP client")
+		}
+		return o.httpClient, nil
+	}
+	if custom && (o.defaultClient == nil || o.transport != o.defaultTransport) {
+		return o.transportCache.client(o.transport), nil
+	}
+	if o.defaultClient != nil {
+		return o.defaultClient, nil
+	}
+	return http.DefaultClient, nil
+}
+
+// successful tells whether a status code means the request succeeded
+func (o *options) successful(code int) bool {
+	if o.successStatus != nil {
+		return o.successStatus(code)
+	}
+	return code >= 200 && code < 300
+}
+
+// requestContentType returns the Content-Type header of the request
+func (o *options) requestContentType() string {
+	if o.contentType != "" {
+		return o.contentType
+	}
+	return "application/json"
+}
+
+// setHeader adds a header to set on the request before it's signed
+func (o *options) setHeader(key, value string) {
+	if o.header == nil {
+		o.header = make(http.Header)
+	}
+	o.header.Set(key, value)
+}
+
+// validateData runs the data validator, if any
+func (o *options) validateData(data json.RawMessage) error {
+	if o.dataValidator == nil {
+		return nil
+	}
+	if err := o.dataValidator(data); err != nil {
+		return fmt.Errorf("invalid response data: %w", err)
+	}
+	return nil
+}
+
+// applyHeaders copies the extra headers onto the request. It must run before signing
+func (o *options) applyHeaders(req *http.Request) {
+	for key, values := range o.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+}
+
+// applyAuthorizationHeader renames the Authorization header produced by the signer, if requested
+func (o *options) applyAuthorizationHeader(req *http.Request) {
+	name := http.CanonicalHeaderKey(o.authorizationHeader)
+	if name == "" || name == authorizationHeader {
+		return
+	}
+	req.Header.Set(name, req.Header.Get(authorizationHeader))
+	req.Header.Del(authorizationHeader)
+}
@@ -0,0 +1,105 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// headerServer records the headers of the last request it received
+func headerServer(t *testing.T) (*httptest.Server, *http.Header) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &header
+}
+
+func TestAuthorizationHeader(t *testing.T) {
+	server, header := headerServer(t)
+	tests := []struct {
+		name   string
+		opts   []Option
+		signed string
+		absent string
+	}{
+		{"default", nil, "Authorization", ""},
+		{"renamed", []Option{WithAuthorizationHeader("X-Authorization")}, "X-Authorization", "Authorization"},
+		{"same name", []Option{WithAuthorizationHeader("authorization")}, "Authorization", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				tt.opts...)
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if signature := header.Get(tt.signed); !strings.HasPrefix(signature, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+				t.Errorf("got %s %q, want the signature", tt.signed, signature)
+			}
+			if tt.absent != "" && header.Get(tt.absent) != "" {
+				t.Errorf("the signature was also sent in %s", tt.absent)
+			}
+		})
+	}
+}
+
+func TestSigningTime(t *testing.T) {
+	server, header := headerServer(t)
+	tests := []struct {
+		name  string
+		time  time.Time
+		date  string
+		scope string
+	}{
+		{"utc", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), "20240301T120000Z", "/20240301/eu-west-1/"},
+		{"local before midnight", time.Date(2024, 2, 29, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600)),
+			"20240301T013000Z", "/20240301/eu-west-1/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// AWS would reject the old signing time, not the test server
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				WithSigningTime(tt.time))
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if date := header.Get("X-Amz-Date"); date != tt.date {
+				t.Errorf("got X-Amz-Date %q, want %q", date, tt.date)
+			}
+			if authorization := header.Get("Authorization"); !strings.Contains(authorization, tt.scope) {
+				t.Errorf("got authorization %q, want the scope %q", authorization, tt.scope)
+			}
+		})
+	}
+}
+
+func TestSignedContentLength(t *testing.T) {
+	server, header := headerServer(t)
+	tests := []struct {
+		name   string
+		opts   []Option
+		signed bool
+	}{
+		{"default", nil, false},
+		{"signed", []Option{WithSignedContentLength()}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := APIGatewayWithContext(context.Background(), []byte(`{"id":1}`), server.URL, "eu-west-1",
+				http.MethodPost, testCreds, tt.opts...)
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			signedHeaders := header.Get("Authorization")
+			if signed := strings.Contains(signedHeaders, "content-length"); signed != tt.signed {
+				t.Errorf("got authorization %q, want content-length signed: %v", signedHeaders, tt.signed)
+			}
+		})
+	}
+}
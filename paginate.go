@@ -0,0 +1,85 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// nextTokenVariable is the variable Paginate passes the token of the next page in
+const nextTokenVariable = "nextToken"
+
+// Paginate sends a cursor-paginated list query through the client until the last page, and returns the items of every
+// page. itemsPath and nextTokenPath are the dot-separated paths of the items and of the next token in the data, e.g.
+// "listPosts.items" and "listPosts.nextToken". The token of the next page is passed in the $nextToken variable, so
+// req.Variables must be a map or a struct encoding to a JSON object, and the query must declare it:
+//
+//	query ListPosts($nextToken: String) { listPosts(limit: 100, nextToken: $nextToken) { items { id } nextToken } }
+//
+// It stops at the first error, returning the items read so far, and between pages once ctx is done.
+func Paginate[T any](
+	ctx context.Context, client Sender, req GraphQLRequest, itemsPath, nextTokenPath string, opts ...Option,
+) ([]T, error) {
+	variables, err := variablesMap(req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	for {
+		data, err := client.AppSyncQuery(ctx, req, opts...)
+		if err != nil {
+			return items, err
+		}
+		var page []T
+		if err := decodeData(dataAt(data, itemsPath), &page); err != nil {
+			return items, err
+		}
+		items = append(items, page...)
+
+		var next *string
+		if err := decodeData(dataAt(data, nextTokenPath), &next); err != nil {
+			return items, err
+		}
+		if next == nil || *next == "" {
+			return items, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return items, err
+		}
+		variables[nextTokenVariable] = *next
+		req.Variables = variables
+	}
+}
+
+// variablesMap copies the variables of a request into a map the next token can be set in
+func variablesMap(variables interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if variables == nil {
+		return result, nil
+	}
+	encoded, err := json.Marshal(variables)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode variables: %w", err)
+	}
+	if isNull(encoded) {
+		return result, nil
+	}
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return nil, fmt.Errorf("could not paginate, variables must encode to an object: %w", err)
+	}
+	return result, nil
+}
+
+// dataAt returns the value at a dot-separated path of data, or nil when a field is missing
+func dataAt(data json.RawMessage, path string) json.RawMessage {
+	for _, field := range strings.Split(path, ".") {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) != nil {
+			return nil
+		}
+		data = fields[field]
+	}
+	return data
+}
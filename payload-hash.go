@@ -0,0 +1,45 @@
+package iamsigned
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// UnsignedPayload can be given to WithPayloadHash to sign a request without covering its body, for the services
+	// that accept it
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	payloadHashHeader = "X-Amz-Content-Sha256"
+)
+
+// WithPayloadHash signs the request with the given payload hash (a hex-encoded SHA-256, or UnsignedPayload) instead
+// of hashing the body that is sent. The hash is also sent in the X-Amz-Content-Sha256 header. It is meant for proxies
+// and streaming, where the signed and sent bytes may legitimately differ: a wrong hash gets the request rejected.
+func WithPayloadHash(hash string) Option {
+	if hash != UnsignedPayload {
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != 32 {
+			return func(o *options) {
+				o.err = fmt.Errorf("invalid payload hash '%s'", hash)
+			}
+		}
+	}
+	return func(o *options) {
+		o.setHeader(payloadHashHeader, hash)
+	}
+}
+
+// sendPayloadHash sends the X-Amz-Content-Sha256 header unless the caller gave one, for the services requiring it
+func (o *options) sendPayloadHash() {
+	o.hashPayload = true
+}
+
+// setPayloadHash sets the X-Amz-Content-Sha256 header to the hash of payload, as sent (compressed included), when
+// requested
+func (o *options) setPayloadHash(payload []byte) {
+	if o.hashPayload && o.body == nil && o.header.Get(payloadHashHeader) == "" {
+		hash := sha256.Sum256(payload)
+		o.setHeader(payloadHashHeader, hex.EncodeToString(hash[:]))
+	}
+}
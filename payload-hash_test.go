@@ -0,0 +1,116 @@
+package iamsigned
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPayloadHash(t *testing.T) {
+	var hash, body, authorization string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, _ := io.ReadAll(r.Body)
+		hash, body, authorization = r.Header.Get("X-Amz-Content-Sha256"), string(data), r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	payload := `{"sent":true}`
+	signed := sha256.Sum256([]byte(`{"signed":true}`))
+
+	tests := []struct {
+		name    string
+		hash    string
+		want    string
+		invalid bool
+	}{
+		// API Gateway doesn't need the header, only the signature covers the hash
+		{"default", "", "", false},
+		{"unsigned", UnsignedPayload, UnsignedPayload, false},
+		{"precomputed", hex.EncodeToString(signed[:]), hex.EncodeToString(signed[:]), false},
+		{"not hex", "not-a-hash", "", true},
+		{"too short", "abcd", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, hash = 0, ""
+			var opts []Option
+			if tt.hash != "" {
+				opts = append(opts, WithPayloadHash(tt.hash))
+			}
+			_, err := Deliver(context.Background(), []byte(payload), APIGatewayService, server.URL, "eu-west-1",
+				http.MethodPost, testCreds, opts...)
+			if tt.invalid {
+				if err == nil || requests != 0 {
+					t.Errorf("got error %v after %d requests, want an invalid hash error", err, requests)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if hash != tt.want {
+				t.Errorf("got X-Amz-Content-Sha256 %q, want %q", hash, tt.want)
+			}
+			if body != payload {
+				t.Errorf("got body %q, want the payload", body)
+			}
+			if tt.hash != "" && !strings.Contains(authorization, "x-amz-content-sha256") {
+				t.Errorf("got authorization %q, want the hash signed", authorization)
+			}
+		})
+	}
+}
+
+func TestPayloadHashCompressed(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(url string, opts ...Option) error
+	}{
+		{"Lambda URL", func(url string, opts ...Option) error {
+			_, err := LambdaURL(context.Background(), []byte(`{"sent":true}`), url, "eu-west-1", http.MethodPost, testCreds, opts...)
+			return err
+		}},
+		{"OpenSearch Serverless", func(url string, opts ...Option) error {
+			_, err := OpenSearchServerless(context.Background(), []byte(`{"sent":true}`), url+"/orders/_doc", "eu-west-1",
+				http.MethodPost, testCreds, opts...)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		for _, gzipped := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s gzip %v", tt.name, gzipped), func(t *testing.T) {
+				var hash, encoding string
+				var body []byte
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ = io.ReadAll(r.Body)
+					hash, encoding = r.Header.Get("X-Amz-Content-Sha256"), r.Header.Get("Content-Encoding")
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`{}`))
+				}))
+				defer server.Close()
+
+				var opts []Option
+				if gzipped {
+					opts = append(opts, WithGzipRequest())
+				}
+				if err := tt.call(server.URL, opts...); err != nil {
+					t.Fatalf("could not call: %v", err)
+				}
+				if gzipped != (encoding == "gzip") {
+					t.Errorf("got Content-Encoding %q", encoding)
+				}
+				if sent := sha256.Sum256(body); hash != hex.EncodeToString(sent[:]) {
+					t.Errorf("got X-Amz-Content-Sha256 %q, want the hash of the body as sent", hash)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package iamsigned
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// WithPersistedQueries sends the requests of AppSyncQuery as automatic persisted queries: only the SHA-256 hash of
+// the query is sent at first, and the full query is sent along with it when the server answers
+// PersistedQueryNotFound, registering it for the next calls. The server, or a proxy in front of it, must support the
+// protocol. Given to NewClient, the hashes are computed once per query and kept for every call of the Client.
+func WithPersistedQueries() Option {
+	queries := &persistedQueries{}
+	return func(o *options) {
+		o.persisted = queries
+	}
+}
+
+type persistedQueries struct {
+	// hashes maps query texts to their hash
+	hashes sync.Map
+}
+
+// hash returns the hash of query
+func (p *persistedQueries) hash(query string) string {
+	if hash, ok := p.hashes.Load(query); ok {
+		return hash.(string)
+	}
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+	p.hashes.Store(query, hash)
+	return hash
+}
+
+// persistedRequest is a GraphQL request carrying the persistedQuery extension
+type persistedRequest struct {
+	Query         string      `json:"query,omitempty"`
+	Variables     interface{} `json:"variables,omitempty"`
+	OperationName string      `json:"operationName,omitempty"`
+	Extensions    struct {
+		PersistedQuery struct {
+			Version    int    `json:"version"`
+			SHA256Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// appSyncPersisted sends req as a persisted query, then in full when the server doesn't know it
+func appSyncPersisted(ctx context.Context, req GraphQLRequest, endpoint, region string, creds *credentials.Credentials, o *options, opts []Option) (json.RawMessage, error) {
+	hash := o.persisted.hash(req.Query)
+	persisted := persistedRequest{Variables: req.Variables, OperationName: req.OperationName}
+	persisted.Extensions.PersistedQuery.Version = 1
+	persisted.Extensions.PersistedQuery.SHA256Hash = hash
+
+	payload, err := json.Marshal(persisted)
+	if err != nil {
+		return nil, o.wrapError(fmt.Errorf("could not encode GraphQL request: %w", err))
+	}
+	if key := o.header.Get(IdempotencyKeyHeader); o.idempotent && key != "" {
+		// both requests are one logical call, sharing the key generated by an empty WithIdempotencyKey
+		opts = append(opts[:len(opts):len(opts)], WithIdempotencyKey(key))
+	}
+	data, err := AppSyncWithContext(ctx, payload, endpoint, region, creds, opts...)
+	if !persistedQueryNotFound(err) {
+		return data, err
+	}
+
+	persisted.Query = req.Query
+	if payload, err = json.Marshal(persisted); err != nil {
+		return nil, o.wrapError(fmt.Errorf("could not encode GraphQL request: %w", err))
+	}
+	return AppSyncWithContext(ctx, payload, endpoint, region, creds, opts...)
+}
+
+// persistedQueryNotFound tells whether the server asked for the full query
+func persistedQueryNotFound(err error) bool {
+	var errs *GraphQLErrors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	for _, e := range errs.Errors {
+		var extensions struct {
+			Code string `json:"code"`
+		}
+		json.Unmarshal(e.Extensions, &extensions)
+		if e.Message == "PersistedQueryNotFound" || e.ErrorType == "PersistedQueryNotFound" || extensions.Code == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package iamsigned
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPersistedQueryIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.Contains(string(body), `"query"`) {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"createPost":{"id":"1"}}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"generated", ""},
+		{"given", "payment-42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys = nil
+			client := NewClient(server.URL, "eu-west-1", testCreds, WithPersistedQueries(), WithIdempotencyKey(tt.key))
+			_, err := client.AppSyncQuery(context.Background(), GraphQLRequest{Query: "mutation { createPost { id } }"})
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+				t.Errorf("got keys %q, want the same one twice", keys)
+			}
+			if tt.key != "" && keys[0] != tt.key {
+				t.Errorf("got key %q, want %q", keys[0], tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package iamsigned
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrPublicKeyPinMismatch fails the TLS handshake when no certificate presented by the server matches a pin
+var ErrPublicKeyPinMismatch = errors.New("server certificate does not match any pinned public key")
+
+// WithPublicKeyPins only accepts TLS connections where a certificate of the server chain has one of the given public
+// keys, to defend against MITM even with a compromised CA. Pins are base64-encoded SHA-256 hashes of the certificates'
+// SubjectPublicKeyInfo, optionally prefixed with "sha256/" (as produced by
+// `openssl x509 -pubkey | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64`).
+//
+// The check runs in the handshake of a transport shared by the options given the same pins, whatever their order. It
+// can't be combined with WithHTTPClient.
+func WithPublicKeyPins(pins ...string) Option {
+	allowed, err := parsePins(pins)
+	if err != nil {
+		return func(o *options) {
+			o.err = err
+		}
+	}
+	client := pinnedClient(allowed)
+	return func(o *options) {
+		o.pinnedClient = client
+	}
+}
+
+// pinnedClients holds the client of each pin set, bounded for processes building options from many of them. An
+// evicted client keeps working for the options already holding it.
+var pinnedClients = newLRUMap[string, *http.Client](64)
+
+// pinnedClient returns the client enforcing the allowed keys, creating it on first use
+func pinnedClient(allowed map[[sha256.Size]byte]struct{}) *http.Client {
+	hashes := make([]string, 0, len(allowed))
+	for hash := range allowed {
+		hashes = append(hashes, string(hash[:]))
+	}
+	sort.Strings(hashes)
+	key := strings.Join(hashes, "")
+
+	return pinnedClients.get(key, func() *http.Client {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{VerifyConnection: verifyPins(allowed)}
+		return &http.Client{Transport: transport}
+	})
+}
+
+// VerifyPublicKeyPins builds a tls.Config VerifyConnection callback enforcing the given pins (see WithPublicKeyPins),
+// for callers plugging pinning into their own transport
+func VerifyPublicKeyPins(pins ...string) (func(tls.ConnectionState) error, error) {
+	allowed, err := parsePins(pins)
+	if err != nil {
+		return nil, err
+	}
+	return verifyPins(allowed), nil
+}
+
+// parsePins decodes pins into the set of allowed SubjectPublicKeyInfo hashes
+func parsePins(pins []string) (map[[sha256.Size]byte]struct{}, error) {
+	if len(pins) == 0 {
+		return nil, errors.New("no public key pin given")
+	}
+	allowed := make(map[[sha256.Size]byte]struct{}, len(pins))
+	for _, pin := range pins {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid public key pin '%s'", pin)
+		}
+		var hash [sha256.Size]byte
+		copy(hash[:], decoded)
+		allowed[hash] = struct{}{}
+	}
+	return allowed, nil
+}
+
+func verifyPins(allowed map[[sha256.Size]byte]struct{}) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, cert := range cs.PeerCertificates {
+			if _, ok := allowed[spkiHash(cert)]; ok {
+				return nil
+			}
+		}
+		return ErrPublicKeyPinMismatch
+	}
+}
+
+func spkiHash(cert *x509.Certificate) [sha256.Size]byte {
+	return sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+}
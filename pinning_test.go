@@ -0,0 +1,73 @@
+package iamsigned
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyPublicKeyPins(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// the mismatched handshake fails on the server side too
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	hash := spkiHash(server.Certificate())
+	pin := "sha256/" + base64.StdEncoding.EncodeToString(hash[:])
+	other := sha256.Sum256([]byte("another key"))
+
+	tests := []struct {
+		name string
+		pins []string
+		err  error
+	}{
+		{"pinned", []string{pin}, nil},
+		{"one of the pins", []string{base64.StdEncoding.EncodeToString(other[:]), pin}, nil},
+		{"mismatch", []string{base64.StdEncoding.EncodeToString(other[:])}, ErrPublicKeyPinMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify, err := VerifyPublicKeyPins(tt.pins...)
+			if err != nil {
+				t.Fatalf("could not parse pins: %v", err)
+			}
+			pool := x509.NewCertPool()
+			pool.AddCert(server.Certificate())
+			conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+				RootCAs:          pool,
+				ServerName:       "example.com",
+				VerifyConnection: verify,
+			})
+			if err == nil {
+				conn.Close()
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got handshake error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPublicKeyPinsClient(t *testing.T) {
+	first := sha256.Sum256([]byte("first key"))
+	second := sha256.Sum256([]byte("second key"))
+	a, b := base64.StdEncoding.EncodeToString(first[:]), base64.StdEncoding.EncodeToString(second[:])
+
+	client := newOptions([]Option{WithPublicKeyPins(a, b)}).pinnedClient
+	if other := newOptions([]Option{WithPublicKeyPins("sha256/"+b, a)}).pinnedClient; other != client {
+		t.Error("the same pins built different clients")
+	}
+	if other := newOptions([]Option{WithPublicKeyPins(a)}).pinnedClient; other == client {
+		t.Error("different pins share a client")
+	}
+	if err := newOptions([]Option{WithPublicKeyPins("not a pin")}).err; err == nil {
+		t.Error("an invalid pin was accepted")
+	}
+}
@@ -0,0 +1,67 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// maxPresignExpiry is the longest validity SigV4 allows for a presigned URL
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// Presign returns a URL carrying its SigV4 signature in the query string, valid for expires, so a process without
+// credentials (e.g. a browser) can call the endpoint, e.g. with the execute-api or lambda service. payload may be nil;
+// when set, the exact same body must be sent.
+//
+// The returned header holds the headers covered by the signature besides Host (e.g. those set with WithHeader): the
+// request must be sent with them. Custom signers (WithSigner) and SigV4A can't presign.
+func Presign(payload []byte, service AWSService, endpoint, region, method string, expires time.Duration, creds *credentials.Credentials, opts ...Option) (string, http.Header, error) {
+	o := newOptions(opts)
+	if expires <= 0 || expires > maxPresignExpiry {
+		return "", nil, o.wrapError(fmt.Errorf("invalid presigned URL expiry %s, must be within 7 days", expires))
+	}
+	if o.signer != nil || o.algorithm != SigV4 {
+		return "", nil, o.wrapError(errors.New("only the default SigV4 signer can presign URLs"))
+	}
+	endpoint, region, creds, err := o.prepare(endpoint, region, creds)
+	if err != nil {
+		return "", nil, o.wrapError(err)
+	}
+
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return "", nil, o.wrapError(fmt.Errorf("could not create request: %w", err))
+	}
+	o.applyHeaders(req)
+	header, err := v4.NewSigner(creds).Presign(req, bytes.NewReader(payload), string(service), region, expires, o.now())
+	if err != nil {
+		return "", nil, o.wrapError(&signingError{err: err})
+	}
+	header.Del("Host")
+	return req.URL.String(), header, nil
+}
+
+// SendPresigned sends a request that was already signed (e.g. captured earlier) as is, and returns the raw response.
+// The Authorization and X-Amz-* headers are left untouched, and the status code is not checked: the caller owns and
+// must close the response body.
+//
+// A SigV4 signature is only accepted for a few minutes after its X-Amz-Date, so replaying a request past that window
+// fails with a 403.
+func SendPresigned(ctx context.Context, req *http.Request, opts ...Option) (*http.Response, error) {
+	o := newOptions(opts)
+	client, err := o.client()
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	response, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, o.wrapError(fmt.Errorf("could not send request: %w", err))
+	}
+	return response, nil
+}
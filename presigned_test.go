@@ -0,0 +1,58 @@
+package iamsigned
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendPresigned(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		if r.URL.Path == "/expired" {
+			// what AWS answers to a signature replayed too late
+			http.Error(w, `{"message":"Signature expired"}`, http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"fresh", "/prod", http.StatusOK},
+		{"expired", "/expired", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			signTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			if err := SignRequest(req, APIGatewayService, "eu-west-1", testCreds, signTime); err != nil {
+				t.Fatalf("could not sign: %v", err)
+			}
+			signed := req.Header.Clone()
+
+			response, err := SendPresigned(context.Background(), req)
+			if err != nil {
+				t.Fatalf("could not send: %v", err)
+			}
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			if response.StatusCode != tt.status {
+				t.Errorf("got status %d, want %d", response.StatusCode, tt.status)
+			}
+			for _, name := range []string{"Authorization", "X-Amz-Date"} {
+				if received.Get(name) != signed.Get(name) {
+					t.Errorf("got %s %q, want the signed %q", name, received.Get(name), signed.Get(name))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+)
+
+// RateLimiter paces requests. *rate.Limiter (golang.org/x/time/rate) implements it.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, or fails when ctx is done first (or the wait would exceed its deadline)
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimiter waits for limiter before signing every attempt, retries included, e.g. to stay within the
+// account-level or usage plan limits of API Gateway. Share the same limiter between calls (or give it to NewClient)
+// for it to take effect.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// waitRateLimit blocks until the rate limiter, if any, lets the request go
+func (o *options) waitRateLimit(ctx context.Context) error {
+	if o.limiter == nil {
+		return nil
+	}
+	if err := o.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("could not wait for rate limiter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// defaultKeepAliveTimeout is used until AppSync tells the actual one in its connection_ack
+const defaultKeepAliveTimeout = 5 * time.Minute
+
+// ErrRealtimeClosed is returned when using a RealtimeClient that was closed
+var ErrRealtimeClosed = errors.New("realtime connection closed")
+
+type (
+	// realtimeMessage is a message of the AppSync real-time protocols, GraphQL subscriptions (payload) and Events
+	// (channel, event...) alike
+	realtimeMessage struct {
+		ID                  string            `json:"id,omitempty"`
+		Type                string            `json:"type"`
+		Payload             json.RawMessage   `json:"payload,omitempty"`
+		Channel             string            `json:"channel,omitempty"`
+		Authorization       map[string]string `json:"authorization,omitempty"`
+		Event               json.RawMessage   `json:"event,omitempty"`
+		Errors              json.RawMessage   `json:"errors,omitempty"`
+		ConnectionTimeoutMs int               `json:"connectionTimeoutMs,omitempty"`
+	}
+
+	realtimeErrorPayload struct {
+		Errors json.RawMessage `json:"errors"`
+	}
+)
+
+// SubscriptionEvent is a message received for a subscription: either its data, or an error (a *GraphQLErrors for
+// GraphQL errors)
+type SubscriptionEvent struct {
+	Data json.RawMessage
+	Err  error
+}
+
+// realtimeConn is a WebSocket connection to an AppSync real-time endpoint, over which subscriptions are multiplexed.
+// RealtimeClient and EventsClient speak their own protocol over it.
+type realtimeConn struct {
+	conn    *websocket.Conn
+	timeout time.Duration
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	subscriptions map[string]*realtimeSubscription
+	acks          map[string]chan error
+	err           error
+	done          chan struct{}
+}
+
+// realtimeSubscription delivers the events of a subscription. Events are only sent and the channel only closed under
+// mu, and stop is closed before taking it, so a pending delivery never races with closing the channel.
+type realtimeSubscription struct {
+	events   chan SubscriptionEvent
+	stop     chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
+	closed   bool
+}
+
+// dialRealtime opens the WebSocket, and performs the connection_init handshake
+func dialRealtime(ctx context.Context, config *websocket.Config) (*realtimeConn, error) {
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to realtime endpoint: %w", err)
+	}
+	c := &realtimeConn{
+		conn:          conn,
+		timeout:       defaultKeepAliveTimeout,
+		subscriptions: make(map[string]*realtimeSubscription),
+		acks:          make(map[string]chan error),
+		done:          make(chan struct{}),
+	}
+
+	if err := c.send(realtimeMessage{Type: "connection_init"}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+	for {
+		var msg realtimeMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("could not initialize realtime connection: %w", err)
+		}
+		switch msg.Type {
+		case "connection_ack":
+			// GraphQL subscriptions send the timeout in the payload, Events next to the type
+			var ack struct {
+				ConnectionTimeoutMs int `json:"connectionTimeoutMs"`
+			}
+			if json.Unmarshal(msg.Payload, &ack) != nil || ack.ConnectionTimeoutMs <= 0 {
+				ack.ConnectionTimeoutMs = msg.ConnectionTimeoutMs
+			}
+			if ack.ConnectionTimeoutMs > 0 {
+				c.timeout = time.Duration(ack.ConnectionTimeoutMs) * time.Millisecond
+			}
+			return c, nil
+		case "connection_error", "error":
+			conn.Close()
+			return nil, fmt.Errorf("realtime connection refused: %w", realtimeError(msg))
+		}
+	}
+}
+
+// subscribe registers a subscription under id, sends its start message, and waits for the server to acknowledge it.
+// When ctx is done first, the subscription is stopped with a message of the stop type.
+func (c *realtimeConn) subscribe(
+	ctx context.Context, id string, start realtimeMessage, stop string,
+) (*realtimeSubscription, error) {
+	sub := &realtimeSubscription{events: make(chan SubscriptionEvent, 16), stop: make(chan struct{})}
+	ack := make(chan error, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.subscriptions[id] = sub
+	c.acks[id] = ack
+	c.mu.Unlock()
+
+	if err := c.send(start); err != nil {
+		c.remove(id)
+		return nil, err
+	}
+	select {
+	case err := <-ack:
+		if err != nil {
+			c.remove(id)
+			return nil, err
+		}
+		return sub, nil
+	case <-ctx.Done():
+		if c.remove(id) {
+			c.send(realtimeMessage{ID: id, Type: stop})
+		}
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.Err()
+	}
+}
+
+// Err returns the error that ended the connection, if any
+func (c *realtimeConn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close closes the connection, ending every subscription
+func (c *realtimeConn) Close() error {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = ErrRealtimeClosed
+	}
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+func (c *realtimeConn) send(msg realtimeMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := websocket.JSON.Send(c.conn, msg); err != nil {
+		return fmt.Errorf("could not send %s message: %w", msg.Type, err)
+	}
+	return nil
+}
+
+// remove unregisters a subscription, and closes its channel. It returns false if it was already removed.
+func (c *realtimeConn) remove(id string) bool {
+	c.mu.Lock()
+	sub, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	delete(c.acks, id)
+	c.mu.Unlock()
+	if ok {
+		sub.close()
+	}
+	return ok
+}
+
+// readLoop passes incoming messages to dispatch until the connection fails, or dispatch returns an error. A missing
+// keep-alive is detected through the read deadline, pushed back by every message.
+func (c *realtimeConn) readLoop(dispatch func(msg realtimeMessage) error) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+		var msg realtimeMessage
+		if err := websocket.JSON.Receive(c.conn, &msg); err != nil {
+			var netErr interface{ Timeout() bool }
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("no keep-alive received within %s", c.timeout)
+			}
+			c.fail(fmt.Errorf("realtime connection lost: %w", err))
+			return
+		}
+		if err := dispatch(msg); err != nil {
+			c.fail(err)
+			return
+		}
+	}
+}
+
+// acknowledge resolves a pending start, returning false if there was none
+func (c *realtimeConn) acknowledge(id string, err error) bool {
+	c.mu.Lock()
+	ack, ok := c.acks[id]
+	delete(c.acks, id)
+	c.mu.Unlock()
+	if ok {
+		ack <- err
+	}
+	return ok
+}
+
+// deliver hands an event to a subscription, waiting for it to be read unless the subscription is closed
+func (c *realtimeConn) deliver(id string, event SubscriptionEvent) {
+	c.mu.Lock()
+	sub, ok := c.subscriptions[id]
+	c.mu.Unlock()
+	if ok {
+		sub.deliver(event, true)
+	}
+}
+
+// fail ends every subscription with err, unless the client was closed on purpose
+func (c *realtimeConn) fail(err error) {
+	c.mu.Lock()
+	closed := c.err != nil
+	if !closed {
+		c.err = err
+	}
+	subscriptions := c.subscriptions
+	c.subscriptions = make(map[string]*realtimeSubscription)
+	c.acks = make(map[string]chan error)
+	c.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		if !closed {
+			sub.deliver(SubscriptionEvent{Err: err}, false)
+		}
+		sub.close()
+	}
+	c.conn.Close()
+	close(c.done)
+}
+
+// deliver sends event unless the subscription is closed. When wait is false, the event is dropped if the channel is
+// full.
+func (s *realtimeSubscription) deliver(event SubscriptionEvent, wait bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if !wait {
+		select {
+		case s.events <- event:
+		default:
+		}
+		return
+	}
+	select {
+	case s.events <- event:
+	case <-s.stop:
+	}
+}
+
+// close closes the events channel, once any pending delivery gave up
+func (s *realtimeSubscription) close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+// realtimeError converts the errors of an error message, found next to its type (Events) or in its payload (GraphQL
+// subscriptions)
+func realtimeError(msg realtimeMessage) error {
+	raw := msg.Errors
+	if len(raw) == 0 {
+		var payload realtimeErrorPayload
+		if json.Unmarshal(msg.Payload, &payload) == nil {
+			raw = payload.Errors
+		}
+	}
+	if errs, err := newGraphQLErrors(raw); err == nil && errs != nil {
+		return errs
+	}
+	if len(msg.Payload) > 0 {
+		return fmt.Errorf("realtime error: %s", snippet(msg.Payload))
+	}
+	return fmt.Errorf("realtime error: %s", msg.Type)
+}
@@ -0,0 +1,97 @@
+package iamsigned
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// maxRedirects is the number of redirects followed before a call fails, as net/http does
+const maxRedirects = 10
+
+// signatureHeaders are set by signing, so they are dropped from redirected requests before they are signed again
+var signatureHeaders = []string{authorizationHeader, "X-Amz-Date", "X-Amz-Security-Token", "X-Amz-Region-Set"}
+
+// WithRedirects follows redirects to the host of the request and to the given hosts (e.g. "api.example.com"),
+// signing each redirected request again, as net/http would send it with the signature of the original host. Other
+// redirects, and the ones from https to http, are not followed.
+//
+// By default, whatever the CheckRedirect of the HTTP client, redirects are not followed: they fail with an *HTTPError
+// matching ErrRedirect, whose Location tells where the server redirected to. Streamed bodies (DeliverReader) are never
+// redirected.
+func WithRedirects(hosts ...string) Option {
+	trusted := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		trusted[strings.ToLower(host)] = true
+	}
+	return func(o *options) {
+		o.redirectHosts = trusted
+	}
+}
+
+// sendingClient returns the HTTP client to send the request with, applying the redirect policy
+func (o *options) sendingClient(
+	service AWSService, region string, creds *credentials.Credentials,
+) (*http.Client, error) {
+	client, err := o.client()
+	if err != nil {
+		return nil, err
+	}
+	redirecting := *client
+	redirecting.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !o.followsRedirect(req.URL, via[0].URL) {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return o.signRedirect(req, via[len(via)-1], service, region, creds)
+	}
+	return &redirecting, nil
+}
+
+// followsRedirect tells whether a redirect of the request sent to origin to target is followed
+func (o *options) followsRedirect(target, origin *url.URL) bool {
+	if o.redirectHosts == nil || o.body != nil || origin.Scheme == "https" && target.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(target.Hostname())
+	return host == strings.ToLower(origin.Hostname()) || o.redirectHosts[host]
+}
+
+// signRedirect signs a redirected request again, with the body net/http sends along, if any
+func (o *options) signRedirect(
+	req, previous *http.Request, service AWSService, region string, creds *credentials.Credentials,
+) error {
+	for _, name := range signatureHeaders {
+		req.Header.Del(name)
+	}
+	if o.authorizationHeader != "" {
+		req.Header.Del(o.authorizationHeader)
+	}
+
+	var payload []byte
+	if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("could not read redirected request body: %w", err)
+		}
+		defer body.Close()
+		if payload, err = io.ReadAll(body); err != nil {
+			return fmt.Errorf("could not read redirected request body: %w", err)
+		}
+	}
+	// a 301, 302 or 303 turns the request into a GET without body, which the payload hash must match
+	if hash := req.Header.Get(payloadHashHeader); hash != "" && hash != UnsignedPayload && req.Method != previous.Method {
+		empty := sha256.Sum256(nil)
+		req.Header.Set(payloadHashHeader, hex.EncodeToString(empty[:]))
+	}
+	return signRequest(req.Context(), req, bytes.NewReader(payload), service, region, creds, o)
+}
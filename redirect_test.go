@@ -0,0 +1,62 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirects(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		follow bool
+	}{
+		{"not followed by default", nil, false},
+		{"followed to an allowed host", []Option{WithRedirects("localhost")}, true},
+		{"refused outside the allowed hosts", []Option{WithRedirects("api.example.com")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var targetHost, targetAuth string
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				targetHost, targetAuth = r.Host, r.Header.Get(authorizationHeader)
+				w.Write([]byte(`{"from":"target"}`))
+			}))
+			defer target.Close()
+			// the target is reached through another host name than the origin
+			location := strings.Replace(target.URL, "127.0.0.1", "localhost", 1) + "/moved"
+			var originAuth string
+			origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				originAuth = r.Header.Get(authorizationHeader)
+				http.Redirect(w, r, location, http.StatusTemporaryRedirect)
+			}))
+			defer origin.Close()
+
+			data, err := APIGatewayWithContext(context.Background(), []byte(`{}`), origin.URL, "eu-west-1", http.MethodPost,
+				testCreds, tt.opts...)
+			if !tt.follow {
+				var he *HTTPError
+				if !errors.Is(err, ErrRedirect) || !errors.As(err, &he) || he.Location() != location {
+					t.Fatalf("got %v, want a redirect to %s", err, location)
+				}
+				if targetHost != "" {
+					t.Error("the redirect was followed")
+				}
+				return
+			}
+			if err != nil || string(data) != `{"from":"target"}` {
+				t.Fatalf("got %s, %v, want the response of the target", data, err)
+			}
+			if want := strings.TrimPrefix(strings.TrimSuffix(location, "/moved"), "http://"); targetHost != want {
+				t.Errorf("the redirected request was sent for host %s", targetHost)
+			}
+			if targetAuth == "" || targetAuth == originAuth || !strings.Contains(targetAuth, "SignedHeaders=content-type;host;") {
+				t.Errorf("the redirected request was not signed again: %s (origin: %s)", targetAuth, originAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// ErrNoEndpointResolver is returned by the *Named functions when no EndpointResolver was configured
+var ErrNoEndpointResolver = errors.New("no endpoint resolver configured")
+
+// EndpointResolver maps a logical name (e.g. "orders") to the endpoint and region to call, so environment-specific
+// configuration lives in a single place
+type EndpointResolver interface {
+	ResolveEndpoint(name string) (endpoint, region string, err error)
+}
+
+// EndpointResolverFunc is an adapter to use an ordinary function as an EndpointResolver
+type EndpointResolverFunc func(name string) (endpoint, region string, err error)
+
+// ResolveEndpoint calls f(name)
+func (f EndpointResolverFunc) ResolveEndpoint(name string) (endpoint, region string, err error) {
+	return f(name)
+}
+
+// Endpoint is an endpoint URL along with the region it lives in
+type Endpoint struct {
+	URL    string
+	Region string
+}
+
+// EndpointMap is an EndpointResolver backed by a static map
+type EndpointMap map[string]Endpoint
+
+// ResolveEndpoint looks name up in the map
+func (m EndpointMap) ResolveEndpoint(name string) (endpoint, region string, err error) {
+	e, ok := m[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown endpoint '%s'", name)
+	}
+	return e.URL, e.Region, nil
+}
+
+// WithEndpointResolver sets the resolver used by the *Named functions
+func WithEndpointResolver(r EndpointResolver) Option {
+	return func(o *options) {
+		o.endpointResolver = r
+	}
+}
+
+// AppSyncNamed does the same as AppSyncWithContext, resolving the endpoint and region from a logical name when the
+// request is sent
+func AppSyncNamed(ctx context.Context, payload []byte, name string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	endpoint, region, err := resolveEndpoint(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return AppSyncWithContext(ctx, payload, endpoint, region, creds, opts...)
+}
+
+// APIGatewayNamed does the same as APIGatewayWithContext, resolving the endpoint and region from a logical name when
+// the request is sent
+func APIGatewayNamed(ctx context.Context, payload []byte, name, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	endpoint, region, err := resolveEndpoint(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return APIGatewayWithContext(ctx, payload, endpoint, region, method, creds, opts...)
+}
+
+func resolveEndpoint(name string, opts []Option) (endpoint, region string, err error) {
+	o := newOptions(opts)
+	if o.endpointResolver == nil {
+		return "", "", o.wrapError(ErrNoEndpointResolver)
+	}
+	endpoint, region, err = o.endpointResolver.ResolveEndpoint(name)
+	if err != nil {
+		return "", "", o.wrapError(fmt.Errorf("could not resolve endpoint '%s': %w", name, err))
+	}
+	return endpoint, region, nil
+}
@@ -0,0 +1,56 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIGatewayNamed(t *testing.T) {
+	var region string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the credential scope is AKIDEXAMPLE/<date>/<region>/execute-api/aws4_request
+		region = strings.Split(r.Header.Get("Authorization"), "/")[2]
+		w.Write([]byte(`{"id":"order-1"}`))
+	}))
+	defer server.Close()
+	endpoints := EndpointMap{"orders": {URL: server.URL + "/prod/orders", Region: "eu-west-3"}}
+	failing := EndpointResolverFunc(func(name string) (string, string, error) {
+		return "", "", errors.New("no such environment")
+	})
+
+	tests := []struct {
+		name    string
+		service string
+		opts    []Option
+		region  string
+		err     error
+	}{
+		{"resolved", "orders", []Option{WithEndpointResolver(endpoints)}, "eu-west-3", nil},
+		{"unknown name", "payments", []Option{WithEndpointResolver(endpoints)}, "", nil},
+		{"failing resolver", "orders", []Option{WithEndpointResolver(failing)}, "", nil},
+		{"no resolver", "orders", nil, "", ErrNoEndpointResolver},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region = ""
+			data, err := APIGatewayNamed(context.Background(), nil, tt.service, http.MethodGet, testCreds, tt.opts...)
+			if region != tt.region {
+				t.Errorf("got request signed for %q, want %q", region, tt.region)
+			}
+			if tt.region == "" {
+				// nothing is sent when the name doesn't resolve
+				if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil || string(data) != `{"id":"order-1"}` {
+				t.Errorf("got %s (%v)", data, err)
+			}
+		})
+	}
+}
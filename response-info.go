@@ -0,0 +1,79 @@
+package iamsigned
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// ResponseInfo describes how a request went, beyond its body. See WithResponseInfo.
+type ResponseInfo struct {
+	// Attempts is the number of attempts made, 1 meaning the request was not retried
+	Attempts int
+	// ConnReused tells whether the last attempt was sent on a previously used connection, and ConnWasIdle whether
+	// that connection came from the idle pool
+	ConnReused  bool
+	ConnWasIdle bool
+	// Protocol is the protocol of the last response, e.g. "HTTP/1.1" or "HTTP/2.0"
+	Protocol string
+	// StatusCode and Header are those of the last response, e.g. to read pagination tokens set by an integration
+	StatusCode int
+	Header     http.Header
+	// RequestID is the AWS request id of the last response (x-amzn-RequestId and the like), to look the call up in
+	// CloudWatch
+	RequestID string
+	// Latency is how long the call took to get its response, all attempts included
+	Latency time.Duration
+}
+
+// WithResponseInfo fills info once the request completes, whether it succeeded or not. Connection details are
+// collected with net/http/httptrace, only when this option is used.
+//
+// It only applies to a single call: given to NewClient, every call of the Client fails, as concurrent calls would
+// fill the same info.
+func WithResponseInfo(info *ResponseInfo) Option {
+	return func(o *options) {
+		o.info = info
+	}
+}
+
+// recordAttempt notes that the given attempt is being made
+func (o *options) recordAttempt(attempt int) {
+	o.attempt = attempt
+	if o.info != nil {
+		o.info.Attempts = attempt
+	}
+}
+
+// traceContext instruments the request context to collect connection details
+func (o *options) traceContext(ctx context.Context) context.Context {
+	if o.info == nil {
+		return ctx
+	}
+	info := o.info
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(conn httptrace.GotConnInfo) {
+			info.ConnReused = conn.Reused
+			info.ConnWasIdle = conn.WasIdle
+		},
+	})
+}
+
+// recordResponse notes the details of a response
+func (o *options) recordResponse(response *http.Response) {
+	o.response = response
+	if o.info != nil {
+		o.info.Protocol = response.Proto
+		o.info.StatusCode = response.StatusCode
+		o.info.Header = response.Header
+		o.info.RequestID = requestID(response.Header)
+	}
+}
+
+// recordLatency notes how long the call took since start
+func (o *options) recordLatency(start time.Time) {
+	if o.info != nil {
+		o.info.Latency = time.Since(start)
+	}
+}
@@ -0,0 +1,106 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-RequestId", "request-1")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	var info ResponseInfo
+	client := NewClient(server.URL, "eu-west-1", testCreds)
+	if _, err := client.APIGateway(ctx, nil, http.MethodGet, WithResponseInfo(&info)); err != nil {
+		t.Fatalf("could not call: %v", err)
+	}
+	if info.Attempts != 1 || info.StatusCode != http.StatusOK || info.RequestID != "request-1" || info.Protocol == "" {
+		t.Errorf("got info %+v", info)
+	}
+
+	client = NewClient(server.URL, "eu-west-1", testCreds, WithResponseInfo(&info))
+	if _, err := client.APIGateway(ctx, nil, http.MethodGet); err == nil {
+		t.Error("WithResponseInfo was accepted by NewClient")
+	}
+}
+
+func TestResponseInfoConnection(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	http1 := httptest.NewTLSServer(handler)
+	defer http1.Close()
+	http2 := httptest.NewUnstartedServer(handler)
+	http2.EnableHTTP2 = true
+	http2.StartTLS()
+	defer http2.Close()
+
+	tests := []struct {
+		name     string
+		server   *httptest.Server
+		protocol string
+	}{
+		{"HTTP/1.1", http1, "HTTP/1.1"},
+		{"HTTP/2", http2, "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.server.URL, "eu-west-1", testCreds, trusting(tt.server))
+			defer client.CloseIdleConnections()
+			for i, reused := range []bool{false, true} {
+				var info ResponseInfo
+				if _, err := client.APIGateway(context.Background(), nil, http.MethodGet, WithResponseInfo(&info)); err != nil {
+					t.Fatalf("could not call: %v", err)
+				}
+				if info.ConnReused != reused || info.ConnWasIdle != reused || info.Protocol != tt.protocol {
+					t.Errorf("call %d: got %+v, want reused: %v over %s", i, info, reused, tt.protocol)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseInfoAttempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		status   int
+		fails    bool
+	}{
+		{"first try", []int{200}, 1, http.StatusOK, false},
+		{"retried", []int{503, 503, 200}, 3, http.StatusOK, false},
+		{"retries exhausted", []int{503, 503, 503}, 3, http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Amzn-RequestId", fmt.Sprintf("request-%d", requests+1))
+				w.WriteHeader(tt.statuses[requests])
+				requests++
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			var info ResponseInfo
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				WithResponseInfo(&info), WithMaxAttempts(3), WithRetryBackoff(time.Millisecond, time.Millisecond))
+			if fails := err != nil; fails != tt.fails {
+				t.Fatalf("got error %v, want failure: %v", err, tt.fails)
+			}
+			want := fmt.Sprintf("request-%d", len(tt.statuses))
+			if info.Attempts != tt.attempts || info.StatusCode != tt.status || info.RequestID != want {
+				t.Errorf("got info %+v, want %d attempts ending with %d (%s)", info, tt.attempts, tt.status, want)
+			}
+		})
+	}
+}
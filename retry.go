@@ -0,0 +1,230 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// defaultRetryableStatusCodes are retried when retries are enabled with WithMaxAttempts
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// transportError is a failure to get a response at all (connection refused, reset...)
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return fmt.Sprintf("could not send request: %s", e.err)
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// WithMaxAttempts makes up to n attempts (the first one included) when a request fails in a retryable way: a
+// transport error, or a retryable status code. It defaults to 1, meaning no retries.
+//
+// Attempts are spaced by an exponential backoff with full jitter (see WithRetryBackoff), or by the delay of the
+// Retry-After header when the response has one. Each attempt is signed again, as signatures embed a timestamp.
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		o.attempts = n
+	}
+}
+
+// WithRetryableStatusCodes replaces the status codes that trigger a retry (429, 500, 502, 503 and 504 by default)
+func WithRetryableStatusCodes(codes ...int) Option {
+	set := make(map[int]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return WithRetryPredicate(func(statusCode int, _ http.Header) bool {
+		_, ok := set[statusCode]
+		return ok
+	})
+}
+
+// WithRetryPredicate decides which responses are retried, from their status code and headers (e.g. to inspect
+// Retry-After). It replaces WithRetryableStatusCodes: the last of the two given applies.
+func WithRetryPredicate(retryable func(statusCode int, header http.Header) bool) Option {
+	return func(o *options) {
+		o.retryPredicate = retryable
+	}
+}
+
+// WithRetryClassifier takes full control over retries: classify is called after every attempt but the last one, with
+// either the response or the error that prevented getting one, and tells whether to try again. It takes precedence
+// over WithRetryPredicate and WithRetryableStatusCodes.
+func WithRetryClassifier(classify func(response *http.Response, err error) bool) Option {
+	return func(o *options) {
+		o.retryClassifier = classify
+	}
+}
+
+// WithRetryBackoff sets the bounds of the exponential backoff: the n-th retry waits a random delay between 0 and
+// min(max, base * 2^(n-1)). Defaults to 100ms and 5s. A Retry-After delay asked by the server is capped at max too.
+func WithRetryBackoff(base, max time.Duration) Option {
+	return func(o *options) {
+		o.retryBaseDelay = base
+		o.retryMaxDelay = max
+	}
+}
+
+// WithRetryOnBodyError also retries when the response body fails midway (e.g. a connection reset while streaming
+// it), for the helpers that read the whole body. It is off by default, as the request may not be safe to repeat.
+func WithRetryOnBodyError() Option {
+	return func(o *options) {
+		o.retryBodyErrors = true
+	}
+}
+
+// WithRetryByteBudget stops retrying body failures once limit bytes were read over all attempts, partial reads
+// included, so a flaky endpoint can't cause repeated large partial downloads
+func WithRetryByteBudget(limit int64) Option {
+	return func(o *options) {
+		o.retryByteBudget = limit
+	}
+}
+
+func (o *options) maxAttempts() int {
+	if o.attempts < 1 {
+		return 1
+	}
+	return o.attempts
+}
+
+// shouldRetry tells whether the outcome of an attempt calls for another one
+func (o *options) shouldRetry(ctx context.Context, response *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if o.retryClassifier != nil {
+		return o.retryClassifier(response, err)
+	}
+	if err != nil {
+		var te *transportError
+		return errors.As(err, &te)
+	}
+	return o.retryable(response)
+}
+
+// retryable tells whether the response status calls for another attempt
+func (o *options) retryable(response *http.Response) bool {
+	if o.retryPredicate != nil {
+		return o.retryPredicate(response.StatusCode, response.Header)
+	}
+	for _, code := range defaultRetryableStatusCodes {
+		if response.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableBodyError tells whether a failure to read the response body calls for another attempt
+func (o *options) retryableBodyError(err error) bool {
+	if !o.retryBodyErrors && !o.idempotent || errors.Is(err, ErrDecompressedTooLarge) {
+		return false
+	}
+	return o.retryByteBudget <= 0 || o.bytesRead < o.retryByteBudget
+}
+
+// readBody reads and closes body, keeping count of the bytes read over all attempts
+func (o *options) readBody(body io.ReadCloser) ([]byte, error) {
+	defer body.Close()
+	// the extra bytes.MinRead lets ReadFrom detect EOF without growing the buffer
+	buf := bytes.NewBuffer(make([]byte, 0, bodySizeHint(o.response)+bytes.MinRead))
+	_, err := buf.ReadFrom(body)
+	data := buf.Bytes()
+	o.bytesRead += int64(len(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+	return data, nil
+}
+
+// backoff waits before the attempt following the given one, or until ctx is done. A positive retryAfter, as asked
+// by the server, replaces the computed delay, up to the maximum backoff delay.
+func (o *options) backoff(ctx context.Context, attempt int, retryAfter time.Duration, response *http.Response, cause error) error {
+	delay := o.retryDelay(attempt, retryAfter)
+	if err := waitDeadline(ctx, delay); err != nil {
+		return err
+	}
+	o.logRetry(ctx, attempt, response, cause, delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryDelay is the delay before the attempt following the given one: retryAfter when positive, up to the maximum
+// backoff delay, and the computed backoff delay otherwise
+func (o *options) retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter <= 0 {
+		return o.backoffDelay(attempt)
+	}
+	return min(retryAfter, o.maxBackoffDelay())
+}
+
+// jitter returns a random duration in [0, n), replaced by tests
+var jitter = rand.Int63n
+
+// backoffDelay computes an exponential delay with full jitter
+func (o *options) backoffDelay(attempt int) time.Duration {
+	base, max := o.retryBaseDelay, o.maxBackoffDelay()
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	ceiling := base << uint(attempt-1)
+	if ceiling > max || ceiling <= 0 {
+		ceiling = max
+	}
+	return time.Duration(jitter(int64(ceiling) + 1))
+}
+
+// maxBackoffDelay is the longest delay between attempts
+func (o *options) maxBackoffDelay() time.Duration {
+	if o.retryMaxDelay <= 0 {
+		return defaultRetryMaxDelay
+	}
+	return o.retryMaxDelay
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfterIsCapped(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+	}{
+		{"seconds", "3600"},
+		{"date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)},
+		{"overflowing seconds", "99999999999999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			start := time.Now()
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				WithMaxAttempts(2), WithRetryBackoff(time.Millisecond, 50*time.Millisecond))
+			if err != nil {
+				t.Fatalf("could not call: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("waited %s, want at most the 50ms cap", elapsed)
+			}
+			if attempts != 2 {
+				t.Errorf("got %d attempts, want 2", attempts)
+			}
+		})
+	}
+}
+
+func TestRetryableStatusCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		opts     []Option
+		attempts int
+	}{
+		{"default retryable", http.StatusServiceUnavailable, nil, 3},
+		{"default not retryable", http.StatusBadRequest, nil, 1},
+		{"given code", http.StatusConflict, []Option{WithRetryableStatusCodes(http.StatusConflict)}, 3},
+		{"replaced defaults", http.StatusServiceUnavailable, []Option{WithRetryableStatusCodes(http.StatusConflict)}, 1},
+		{"predicate", http.StatusTooManyRequests, []Option{WithRetryPredicate(func(code int, header http.Header) bool {
+			return header.Get("X-Retryable") == "true"
+		})}, 3},
+		{"last one applies", http.StatusConflict, []Option{
+			WithRetryableStatusCodes(http.StatusConflict),
+			WithRetryPredicate(func(int, http.Header) bool { return false }),
+		}, 1},
+		{"single attempt", http.StatusServiceUnavailable, []Option{WithMaxAttempts(1)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.Header().Set("X-Retryable", "true")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			opts := append([]Option{WithMaxAttempts(3), WithRetryBackoff(time.Millisecond, time.Millisecond)}, tt.opts...)
+			_, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				opts...)
+			if err == nil {
+				t.Error("the failing call succeeded")
+			}
+			if attempts != tt.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryOnBodyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		attempts int
+		failed   bool
+	}{
+		{"off by default", nil, 1, true},
+		{"retried", []Option{WithRetryOnBodyError()}, 3, false},
+		{"idempotent", []Option{WithIdempotencyKey("")}, 3, false},
+		{"budget spent", []Option{WithRetryOnBodyError(), WithRetryByteBudget(700)}, 2, true},
+		{"budget left", []Option{WithRetryOnBodyError(), WithRetryByteBudget(1 << 20)}, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.Header().Set("Content-Length", "1000")
+				w.Write(bytes.Repeat([]byte("x"), 500))
+				if attempts < 3 {
+					// the connection drops halfway through the body
+					w.(http.Flusher).Flush()
+					panic(http.ErrAbortHandler)
+				}
+				w.Write(bytes.Repeat([]byte("x"), 500))
+			}))
+			defer server.Close()
+
+			opts := append([]Option{WithMaxAttempts(3), WithRetryBackoff(time.Millisecond, time.Millisecond)}, tt.opts...)
+			data, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+				opts...)
+			if failed := err != nil; failed != tt.failed {
+				t.Errorf("got error %v, want a failure: %v", err, tt.failed)
+			}
+			if !tt.failed && len(data) != 1000 {
+				t.Errorf("got %d bytes, want the whole body", len(data))
+			}
+			if attempts != tt.attempts {
+				t.Errorf("got %d attempts, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	defer func(original func(int64) int64) { jitter = original }(jitter)
+	o := newOptions([]Option{WithRetryBackoff(100*time.Millisecond, time.Second)})
+
+	tests := []struct {
+		name    string
+		attempt int
+		jitter  func(n int64) int64
+		want    time.Duration
+	}{
+		{"first attempt, highest jitter", 1, func(n int64) int64 { return n - 1 }, 100 * time.Millisecond},
+		{"third attempt, highest jitter", 3, func(n int64) int64 { return n - 1 }, 400 * time.Millisecond},
+		{"capped by the maximum delay", 5, func(n int64) int64 { return n - 1 }, time.Second},
+		{"shift overflow", 80, func(n int64) int64 { return n - 1 }, time.Second},
+		{"lowest jitter", 3, func(n int64) int64 { return 0 }, 0},
+		{"half jitter", 2, func(n int64) int64 { return n / 2 }, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jitter = tt.jitter
+			if got := o.retryDelay(tt.attempt, 0); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	defer func(original func(int64) int64) { jitter = original }(jitter)
+	jitter = func(n int64) int64 { return 0 }
+	o := newOptions([]Option{WithRetryBackoff(100*time.Millisecond, time.Minute)})
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		want       time.Duration
+	}{
+		{"none", "", 0},
+		{"seconds", "3", 3 * time.Second},
+		{"date", now.Add(10 * time.Second).UTC().Format(http.TimeFormat), 10 * time.Second},
+		{"past date", now.Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+		{"capped", "3600", time.Minute},
+		{"invalid", "soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			got := o.retryDelay(1, parseRetryAfter(header, now))
+			// HTTP dates only have a precision of a second
+			if got < tt.want-time.Second || got > tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
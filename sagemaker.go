@@ -0,0 +1,48 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+const sageMakerCustomAttributesHeader = "X-Amzn-Sagemaker-Custom-Attributes"
+
+// SageMakerResponse is the response of a SageMaker endpoint invocation
+type SageMakerResponse struct {
+	// Body is the inference, of type ContentType
+	Body        []byte
+	ContentType string
+	// CustomAttributes are those returned by the model, if any
+	CustomAttributes string
+	// InvokedVariant is the production variant that served the request
+	InvokedVariant string
+}
+
+// WithSageMakerCustomAttributes passes custom attributes to the model, in the X-Amzn-SageMaker-Custom-Attributes
+// header (e.g. "trace_id=xyz")
+func WithSageMakerCustomAttributes(attributes string) Option {
+	return WithHeader(sageMakerCustomAttributesHeader, attributes)
+}
+
+// SageMakerInvoke runs a SageMaker InvokeEndpoint call against the endpoint of the given name, on the regional
+// runtime endpoint. The payload is sent as JSON unless another type is given with WithContentType, e.g. text/csv or
+// application/x-image, and the accepted response type can be set with WithHeader("Accept", ...).
+func SageMakerInvoke(ctx context.Context, payload []byte, endpointName, region string, creds *credentials.Credentials, opts ...Option) (*SageMakerResponse, error) {
+	o := newOptions(opts)
+	endpoint := fmt.Sprintf("https://runtime.sagemaker.%s.amazonaws.com/endpoints/%s/invocations", region, escapePathSegment(endpointName))
+	body, err := deliverBytes(ctx, payload, SageMakerService, endpoint, region, http.MethodPost, creds, o)
+	if err != nil {
+		return nil, err
+	}
+	response := &SageMakerResponse{Body: body}
+	// cached responses come without headers
+	if o.response != nil {
+		response.ContentType = o.response.Header.Get("Content-Type")
+		response.CustomAttributes = o.response.Header.Get(sageMakerCustomAttributesHeader)
+		response.InvokedVariant = o.response.Header.Get("X-Amzn-Invoked-Production-Variant")
+	}
+	return response, nil
+}
@@ -0,0 +1,54 @@
+// Package schema validates AppSync response data against a JSON Schema, to catch backend contract drift at the
+// boundary.
+//
+// It is kept out of the iamsigned package so that only callers who need it depend on a JSON Schema implementation.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aherve/iamsigned"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const schemaURL = "iamsigned://schema.json"
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	compiled *jsonschema.Schema
+}
+
+// Compile compiles a JSON Schema document
+func Compile(document []byte) (*Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(document)); err != nil {
+		return nil, fmt.Errorf("could not load schema: %w", err)
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile schema: %w", err)
+	}
+	return &Schema{compiled: compiled}, nil
+}
+
+// Validate checks data against the schema. The returned error wraps a *jsonschema.ValidationError detailing every
+// violation.
+func (s *Schema) Validate(data json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("could not decode data: %w", err)
+	}
+	if err := s.compiled.Validate(value); err != nil {
+		return fmt.Errorf("data does not match the schema: %w", err)
+	}
+	return nil
+}
+
+// Option returns an iamsigned.Option validating AppSync data against the schema
+func (s *Schema) Option() iamsigned.Option {
+	return iamsigned.WithDataValidator(s.Validate)
+}
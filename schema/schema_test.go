@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aherve/iamsigned"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const postSchema = `{
+	"type": "object",
+	"required": ["getPost"],
+	"properties": {
+		"getPost": {"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}}}
+	}
+}`
+
+func TestSchemaOption(t *testing.T) {
+	schema, err := Compile([]byte(postSchema))
+	if err != nil {
+		t.Fatalf("could not compile schema: %v", err)
+	}
+	creds := credentials.NewStaticCredentials("AKIDEXAMPLE", "secret", "")
+
+	tests := []struct {
+		name    string
+		body    string
+		invalid bool
+		graphQL bool
+	}{
+		{"matching", `{"data":{"getPost":{"id":"1"}}}`, false, false},
+		{"wrong type", `{"data":{"getPost":{"id":1}}}`, true, false},
+		{"missing field", `{"data":{}}`, true, false},
+		{"GraphQL errors are not validated", `{"data":null,"errors":[{"message":"Unauthorized"}]}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := iamsigned.AppSyncWithContext(context.Background(), []byte(`{"query":"{ getPost { id } }"}`),
+				server.URL, "eu-west-1", creds, schema.Option())
+			var validationErr *jsonschema.ValidationError
+			if invalid := errors.As(err, &validationErr); invalid != tt.invalid {
+				t.Errorf("got error %v, want a validation error: %v", err, tt.invalid)
+			}
+			if graphQL := errors.Is(err, iamsigned.ErrGraphQL); graphQL != tt.graphQL {
+				t.Errorf("got error %v, want a GraphQL error: %v", err, tt.graphQL)
+			}
+		})
+	}
+}
+
+func TestCompileInvalidSchema(t *testing.T) {
+	if _, err := Compile([]byte(`{"type": 42}`)); err == nil {
+		t.Error("an invalid schema compiled")
+	}
+}
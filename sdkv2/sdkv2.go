@@ -0,0 +1,52 @@
+// Package sdkv2 signs iamsigned requests with aws-sdk-go-v2 credentials providers and signer, for projects that
+// moved to the SDK v2.
+//
+// Callers still using aws-sdk-go v1 credentials don't need it: only programs importing this package link the SDK v2.
+//
+// It doesn't drop the SDK v1 though: the iamsigned functions take v1 *credentials.Credentials in their signatures, so
+// aws-sdk-go stays a dependency of every program using iamsigned. Pass nil v1 credentials along with this package's
+// options.
+package sdkv2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aherve/iamsigned"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Signer is an iamsigned.Signer backed by an aws-sdk-go-v2 credentials provider
+type Signer struct {
+	provider aws.CredentialsProvider
+	signer   *v4.Signer
+}
+
+// NewSigner creates a Signer. A provider that isn't an *aws.CredentialsCache is wrapped in one, so credentials are
+// not retrieved for every request.
+func NewSigner(provider aws.CredentialsProvider, optFns ...func(*v4.SignerOptions)) *Signer {
+	if _, ok := provider.(*aws.CredentialsCache); !ok {
+		provider = aws.NewCredentialsCache(provider)
+	}
+	return &Signer{provider: provider, signer: v4.NewSigner(optFns...)}
+}
+
+// WithCredentialsProvider signs requests with the SDK v2 signer and provider (e.g. aws.Config.Credentials), so calls
+// can pass nil v1 credentials:
+//
+//	iamsigned.AppSync(payload, endpoint, region, nil, sdkv2.WithCredentialsProvider(cfg.Credentials))
+func WithCredentialsProvider(provider aws.CredentialsProvider) iamsigned.Option {
+	return iamsigned.WithSigner(NewSigner(provider))
+}
+
+// SignRequest implements iamsigned.Signer
+func (s *Signer) SignRequest(ctx context.Context, req *http.Request, payloadHash, service, region string, signingTime time.Time) error {
+	creds, err := s.provider.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve credentials: %w", err)
+	}
+	return s.signer.SignHTTP(ctx, creds, req, payloadHash, service, region, signingTime)
+}
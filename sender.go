@@ -0,0 +1,17 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Sender is the set of calls a Client makes. Code depending on a Sender rather than a *Client can be tested with a
+// fake, such as iamsignedtest.Fake.
+type Sender interface {
+	Do(ctx context.Context, payload []byte, service AWSService, method string, opts ...Option) ([]byte, error)
+	AppSync(ctx context.Context, payload []byte, opts ...Option) (json.RawMessage, error)
+	AppSyncQuery(ctx context.Context, req GraphQLRequest, opts ...Option) (json.RawMessage, error)
+	APIGateway(ctx context.Context, payload []byte, method string, opts ...Option) ([]byte, error)
+}
+
+var _ Sender = (*Client)(nil)
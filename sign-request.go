@@ -0,0 +1,76 @@
+package iamsigned
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// SignRequest signs a request built by the caller in place, as if it was sent at signTime (the current time when
+// zero), so it can be sent with any client. Only the options about signing and headers apply (e.g. WithSigner,
+// WithSigningAlgorithm, WithHeader, WithAuthorizationHeader). Nil credentials fall back to the default credentials.
+//
+// The body is hashed without being consumed when it's an io.ReadSeeker or req.GetBody is set, and is otherwise read
+// in memory and replaced. A body the caller doesn't want hashed (e.g. a stream of unknown size) is left untouched
+// when the X-Amz-Content-Sha256 header is set first, e.g. to UnsignedPayload when the service accepts it.
+func SignRequest(req *http.Request, service AWSService, region string, creds *credentials.Credentials, signTime time.Time, opts ...Option) error {
+	o := newOptions(opts)
+	if !signTime.IsZero() {
+		o.signingTime = signTime
+	}
+	_, region, creds, err := o.prepare(req.URL.String(), region, creds)
+	if err != nil {
+		return o.wrapError(err)
+	}
+	hashed, err := hashableBody(req)
+	if err != nil {
+		return o.wrapError(err)
+	}
+
+	ctx := req.Context()
+	o.applyHeaders(req)
+	o.injectTrace(ctx, req)
+	body := req.Body
+	err = signRequest(ctx, req, hashed, service, region, creds, o)
+	// the signer replaces the body with the one it hashed
+	req.Body = body
+	return o.wrapError(err)
+}
+
+// hashableBody returns a reader of the body of req the signer can hash, or nil when it's empty or already hashed by
+// the caller
+func hashableBody(req *http.Request) (io.ReadSeeker, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get(payloadHashHeader) != "" {
+		return nil, nil
+	}
+	if seeker, ok := req.Body.(io.ReadSeeker); ok {
+		return seeker, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+		defer body.Close()
+		payload, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+		return bytes.NewReader(payload), nil
+	}
+
+	payload, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(payload)), nil
+	}
+	req.ContentLength = int64(len(payload))
+	return bytes.NewReader(payload), nil
+}
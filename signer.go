@@ -0,0 +1,48 @@
+package iamsigned
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Signer signs a request in place. payloadHash is the hex-encoded SHA-256 of the body, or UnsignedPayload.
+//
+// By default requests are signed with the aws-sdk-go v1 signer and the credentials given to each call. A Signer set
+// with WithSigner replaces it, in which case calls may pass nil credentials (see the sdkv2 subpackage for an
+// aws-sdk-go-v2 implementation).
+type Signer interface {
+	SignRequest(ctx context.Context, req *http.Request, payloadHash, service, region string, signingTime time.Time) error
+}
+
+// WithSigner signs requests with signer instead of the default aws-sdk-go v1 signer
+func WithSigner(signer Signer) Option {
+	return func(o *options) {
+		o.signer = signer
+	}
+}
+
+// payloadHash returns the hash to sign the body with, and rewinds the body
+func payloadHash(req *http.Request, body io.ReadSeeker) (string, error) {
+	if hash := req.Header.Get(payloadHashHeader); hash != "" {
+		return hash, nil
+	}
+	hash := sha256.New()
+	if body != nil {
+		start, err := body.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", fmt.Errorf("could not hash request body: %w", err)
+		}
+		if _, err := io.Copy(hash, body); err != nil {
+			return "", fmt.Errorf("could not hash request body: %w", err)
+		}
+		if _, err := body.Seek(start, io.SeekStart); err != nil {
+			return "", fmt.Errorf("could not rewind request body: %w", err)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,92 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// SigningDebug holds the intermediate values of a signature, to compare against the ones AWS returns with a 403
+// SignatureDoesNotMatch. The session token is redacted; the secret key is never part of them.
+type SigningDebug struct {
+	CanonicalRequest string
+	StringToSign     string
+	SignedHeaders    []string
+}
+
+// WithSigningDebug passes the canonical request and string to sign of every signature to report, or logs them at
+// Debug level through the logger (see WithLogger) when report is nil. It covers the default SigV4 and the SigV4A
+// signers, not custom ones.
+func WithSigningDebug(report func(SigningDebug)) Option {
+	return func(o *options) {
+		o.debugSigning = true
+		o.signingDebug = report
+	}
+}
+
+var securityTokenLine = regexp.MustCompile(`(?m)^(x-amz-security-token:).*$`)
+
+// newSigningDebug fills a SigningDebug from the canonical request and string to sign
+func newSigningDebug(canonicalRequest, stringToSign string) SigningDebug {
+	canonicalRequest = securityTokenLine.ReplaceAllString(canonicalRequest, "${1}"+redacted)
+	debug := SigningDebug{CanonicalRequest: canonicalRequest, StringToSign: stringToSign}
+	// the signed headers are the line before the payload hash
+	if lines := strings.Split(canonicalRequest, "\n"); len(lines) >= 2 {
+		debug.SignedHeaders = strings.Split(lines[len(lines)-2], ";")
+	}
+	return debug
+}
+
+// reportSigning hands the signing details over, when debugging is enabled
+func (o *options) reportSigning(ctx context.Context, debug SigningDebug) {
+	if !o.debugSigning {
+		return
+	}
+	if o.signingDebug != nil {
+		o.signingDebug(debug)
+		return
+	}
+	o.log().LogAttrs(ctx, slog.LevelDebug, "signed request",
+		slog.String("canonical_request", debug.CanonicalRequest),
+		slog.String("string_to_sign", debug.StringToSign),
+	)
+}
+
+// v4Signer returns the aws-sdk-go v1 signer to sign with creds. With signing debug, a dedicated signer captures the
+// debug output of the SDK.
+func (o *options) v4Signer(ctx context.Context, creds *credentials.Credentials) *v4.Signer {
+	if o.debugSigning {
+		return v4.NewSigner(creds, func(signer *v4.Signer) {
+			signer.Debug = aws.LogDebugWithSigning
+			signer.Logger = aws.LoggerFunc(func(args ...interface{}) {
+				if canonical, stringToSign, ok := parseSigningLog(fmt.Sprint(args...)); ok {
+					o.reportSigning(ctx, newSigningDebug(canonical, stringToSign))
+				}
+			})
+		})
+	}
+	if o.v1Signer != nil && o.v1Signer.Credentials == creds {
+		return o.v1Signer
+	}
+	return v4.NewSigner(creds)
+}
+
+// parseSigningLog extracts the canonical request and string to sign from the debug output of the v1 signer
+func parseSigningLog(msg string) (string, string, bool) {
+	_, rest, ok := strings.Cut(msg, "---[ CANONICAL STRING  ]-----------------------------\n")
+	if !ok {
+		return "", "", false
+	}
+	canonical, rest, ok := strings.Cut(rest, "\n---[ STRING TO SIGN ]--------------------------------\n")
+	if !ok {
+		return "", "", false
+	}
+	stringToSign, _, _ := strings.Cut(rest, "\n-----------------------------------------------------")
+	return canonical, stringToSign, true
+}
@@ -0,0 +1,210 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// SigningAlgorithm is the algorithm requests are signed with
+type SigningAlgorithm int
+
+const (
+	// SigV4 is the default, regional HMAC signature
+	SigV4 SigningAlgorithm = iota
+	// SigV4A is the asymmetric (ECDSA P-256) signature valid in a set of regions, for multi-region endpoints
+	SigV4A
+)
+
+const (
+	sigV4AAlgorithm    = "AWS4-ECDSA-P256-SHA256"
+	amzDateFormat      = "20060102T150405Z"
+	amzShortDateFormat = "20060102"
+)
+
+// WithSigningAlgorithm selects the signing algorithm. With SigV4A, the region given to the call is the region set the
+// signature is valid in: "*" for any region, or a comma-separated list such as "us-east-1,eu-west-1". It has no effect
+// with a custom signer (WithSigner).
+func WithSigningAlgorithm(algorithm SigningAlgorithm) Option {
+	return func(o *options) {
+		o.algorithm = algorithm
+	}
+}
+
+var (
+	sigV4AKeys    sync.Map // access key id => *sigV4AKey
+	nMinusTwoP256 = new(big.Int).Sub(elliptic.P256().Params().N, big.NewInt(2))
+)
+
+type sigV4AKey struct {
+	secret string
+	key    *ecdsa.PrivateKey
+}
+
+// signV4A signs req in place with SigV4A, and returns the signing details
+func signV4A(ctx context.Context, req *http.Request, payloadHash, service, regionSet string, creds *credentials.Credentials, t time.Time) (SigningDebug, error) {
+	value, err := creds.GetWithContext(ctx)
+	if err != nil {
+		return SigningDebug{}, fmt.Errorf("could not get credentials: %w", err)
+	}
+	key, err := sigV4APrivateKey(value.AccessKeyID, value.SecretAccessKey)
+	if err != nil {
+		return SigningDebug{}, err
+	}
+
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Region-Set", regionSet)
+	if value.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", value.SessionToken)
+	}
+	if req.Header.Get(payloadHashHeader) == "" && payloadHash == UnsignedPayload {
+		req.Header.Set(payloadHashHeader, payloadHash)
+	}
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{t.Format(amzShortDateFormat), service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{sigV4AAlgorithm, t.Format(amzDateFormat), scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return SigningDebug{}, fmt.Errorf("could not compute signature: %w", err)
+	}
+	req.Header.Set(authorizationHeader, fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4AAlgorithm, value.AccessKeyID, scope, signedHeaders, hex.EncodeToString(signature)))
+	return newSigningDebug(canonicalRequest, stringToSign), nil
+}
+
+// sigV4APrivateKey derives the ECDSA key of an access key pair, as specified by SigV4A (NIST SP 800-108 KDF in
+// counter mode, with HMAC-SHA256). Keys are cached, as deriving them is comparatively expensive.
+func sigV4APrivateKey(accessKeyID, secret string) (*ecdsa.PrivateKey, error) {
+	if cached, ok := sigV4AKeys.Load(accessKeyID); ok && cached.(*sigV4AKey).secret == secret {
+		return cached.(*sigV4AKey).key, nil
+	}
+
+	inputKey := []byte("AWS4A" + secret)
+	d := new(big.Int)
+	for counter := 1; ; counter++ {
+		if counter > 0xFF {
+			return nil, errors.New("could not derive SigV4A key: exhausted counter")
+		}
+		var kdfContext bytes.Buffer
+		kdfContext.WriteString(accessKeyID)
+		kdfContext.WriteByte(byte(counter))
+
+		candidate := hmacKeyDerivation(inputKey, []byte(sigV4AAlgorithm), kdfContext.Bytes(), 256)
+		if d.SetBytes(candidate).Cmp(nMinusTwoP256) < 0 {
+			break
+		}
+	}
+	d.Add(d, big.NewInt(1))
+
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+	sigV4AKeys.Store(accessKeyID, &sigV4AKey{secret: secret, key: key})
+	return key, nil
+}
+
+// hmacKeyDerivation is the NIST SP 800-108 KDF in counter mode, whose fixed input is label || 0x00 || context || L
+func hmacKeyDerivation(key, label, context []byte, bitLen int) []byte {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(bitLen))
+
+	var output []byte
+	for i := uint32(1); len(output) < bitLen/8; i++ {
+		h := hmac.New(sha256.New, key)
+		binary.Write(h, binary.BigEndian, i)
+		h.Write(label)
+		h.Write([]byte{0x00})
+		h.Write(context)
+		h.Write(length[:])
+		output = h.Sum(output)
+	}
+	return output[:bitLen/8]
+}
+
+// unsignedHeaders are never part of the signature, as proxies may rewrite them
+var unsignedHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+}
+
+// canonicalHeaders returns the signed header list and the canonical headers of req
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string][]string{"host": {host}}
+	for key, v := range req.Header {
+		name := strings.ToLower(key)
+		if unsignedHeaders[name] || name == "host" {
+			continue
+		}
+		values[name] = append(values[name], v...)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		trimmed := make([]string, len(values[name]))
+		for i, value := range values[name] {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		fmt.Fprintf(&canonical, "%s:%s\n", name, strings.Join(trimmed, ","))
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// canonicalURI returns the escaped path of u, escaped once more as SigV4 does for every service but S3
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
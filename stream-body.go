@@ -0,0 +1,100 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// ErrUnseekableBody is returned when a body that is not an io.ReadSeeker is sent without WithPayloadHash: it can't be
+// hashed without holding it in memory
+var ErrUnseekableBody = errors.New("a non-seekable request body requires WithPayloadHash, e.g. with UnsignedPayload")
+
+// DeliverReader does the same as Deliver, streaming the request body from body instead of holding it in memory.
+//
+// An io.ReadSeeker (e.g. an *os.File) is read once to be hashed, then rewound and sent, and rewound again on retries.
+// Its Content-Length is known. Any other io.Reader is sent as is (chunked) and never retried, and requires
+// WithPayloadHash(UnsignedPayload) or a precomputed hash, for the services accepting it. The body is not closed.
+func DeliverReader(ctx context.Context, body io.Reader, service AWSService, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) (io.ReadCloser, error) {
+	o := newOptions(opts)
+	o.body = body
+	response, err := deliverWithContext(ctx, nil, service, endpoint, region, method, creds, o)
+	return response, o.wrapError(err)
+}
+
+// APIGatewayReader does the same as APIGatewayWithContext, streaming the request body from body (see DeliverReader)
+func APIGatewayReader(ctx context.Context, body io.Reader, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	o.body = body
+	return deliverBytes(ctx, nil, APIGatewayService, endpoint, region, method, creds, o)
+}
+
+// resendable tells whether the request body can be sent again
+func (o *options) resendable() bool {
+	if o.body == nil {
+		return true
+	}
+	_, ok := o.body.(io.ReadSeeker)
+	return ok
+}
+
+// newStreamingRequest builds and signs a request whose body is read from o.body
+func newStreamingRequest(ctx context.Context, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (*http.Request, error) {
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", o.requestContentType())
+	o.applyHeaders(req)
+	o.injectTrace(ctx, req)
+
+	seeker, ok := o.body.(io.ReadSeeker)
+	if !ok {
+		if o.bodySent {
+			return nil, errors.New("could not send a non-seekable request body twice")
+		}
+		if req.Header.Get(payloadHashHeader) == "" {
+			return nil, ErrUnseekableBody
+		}
+		o.bodySent = true
+		if err := signRequest(ctx, req, nil, service, region, creds, o); err != nil {
+			return nil, err
+		}
+		// a zero ContentLength with a body is sent chunked
+		req.Body = io.NopCloser(o.body)
+		req.ContentLength = 0
+		return req, nil
+	}
+
+	if !o.bodySent {
+		if o.bodyStart, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("could not read request body: %w", err)
+		}
+		o.bodySent = true
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = seeker.Seek(o.bodyStart, io.SeekStart)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not rewind request body: %w", err)
+	}
+	if o.signContentLength {
+		req.Header.Set("Content-Length", strconv.FormatInt(end-o.bodyStart, 10))
+	}
+	if err := signRequest(ctx, req, seeker, service, region, creds, o); err != nil {
+		return nil, err
+	}
+	// the body belongs to the caller: the transport must not close it between attempts
+	req.Body = io.NopCloser(seeker)
+	req.ContentLength = end - o.bodyStart
+	if req.ContentLength == 0 {
+		req.Body = http.NoBody
+	}
+	return req, nil
+}
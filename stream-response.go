@@ -0,0 +1,36 @@
+package iamsigned
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// StreamResponse is a successful response whose body has not been read. The caller must close Body.
+type StreamResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       io.ReadCloser
+}
+
+// DoStream signs and sends a request to any SigV4 service, checks the status code, and returns the response without
+// buffering its body, e.g. to pipe a large download to disk. Retries only cover the attempts failing before the body
+// is returned.
+func DoStream(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) (*StreamResponse, error) {
+	return deliverStream(ctx, payload, service, endpoint, region, method, creds, newOptions(opts))
+}
+
+// APIGatewayStream does the same as DoStream against API Gateway
+func APIGatewayStream(ctx context.Context, payload []byte, endpoint, region, method string, creds *credentials.Credentials, opts ...Option) (*StreamResponse, error) {
+	return deliverStream(ctx, payload, APIGatewayService, endpoint, region, method, creds, newOptions(opts))
+}
+
+func deliverStream(ctx context.Context, payload []byte, service AWSService, endpoint, region, method string, creds *credentials.Credentials, o *options) (*StreamResponse, error) {
+	body, err := deliverWithContext(ctx, payload, service, endpoint, region, method, creds, o)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	return &StreamResponse{StatusCode: o.response.StatusCode, Header: o.response.Header, Body: body}, nil
+}
@@ -0,0 +1,89 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+const getCallerIdentityPayload = "Action=GetCallerIdentity&Version=2011-06-15"
+
+type (
+	callerIdentityResult struct {
+		Account string `xml:"Account" json:"Account"`
+		Arn     string `xml:"Arn" json:"Arn"`
+		UserID  string `xml:"UserId" json:"UserId"`
+	}
+
+	callerIdentityXMLResponse struct {
+		Result callerIdentityResult `xml:"GetCallerIdentityResult"`
+	}
+
+	callerIdentityJSONResponse struct {
+		Response struct {
+			Result callerIdentityResult `json:"GetCallerIdentityResult"`
+		} `json:"GetCallerIdentityResponse"`
+	}
+)
+
+// CallerIdentity performs a signed sts:GetCallerIdentity call, and returns the identity the credentials sign as.
+// An empty region uses the global STS endpoint.
+func CallerIdentity(ctx context.Context, region string, creds *credentials.Credentials, opts ...Option) (account, arn, userID string, err error) {
+	endpoint, signingRegion := stsEndpoint(region)
+	o := newOptions(opts)
+	o.buffer = true
+	o.contentType = "application/x-www-form-urlencoded; charset=utf-8"
+
+	body, err := deliverWithContext(ctx, []byte(getCallerIdentityPayload), STSService, endpoint, signingRegion, http.MethodPost, creds, o)
+	if err != nil {
+		return "", "", "", o.wrapError(err)
+	}
+	defer body.Close()
+
+	result, err := parseCallerIdentity(body)
+	if err != nil {
+		return "", "", "", o.wrapError(err)
+	}
+	return result.Account, result.Arn, result.UserID, nil
+}
+
+func stsEndpoint(region string) (endpoint, signingRegion string) {
+	switch {
+	case region == "":
+		return "https://sts.amazonaws.com/", "us-east-1"
+	case strings.HasPrefix(region, "cn-"):
+		return fmt.Sprintf("https://sts.%s.amazonaws.com.cn/", region), region
+	default:
+		return fmt.Sprintf("https://sts.%s.amazonaws.com/", region), region
+	}
+}
+
+// parseCallerIdentity reads either the default XML response, or its JSON flavor
+func parseCallerIdentity(body io.Reader) (callerIdentityResult, error) {
+	data, err := readAll(body)
+	if err != nil {
+		return callerIdentityResult{}, fmt.Errorf("could not read buffer: %w", err)
+	}
+
+	content := bytes.TrimSpace(data)
+	if bytes.HasPrefix(content, []byte("{")) {
+		var parsed callerIdentityJSONResponse
+		if err := json.Unmarshal(content, &parsed); err != nil {
+			return callerIdentityResult{}, fmt.Errorf("could not parse response '%s': %w", string(data), err)
+		}
+		return parsed.Response.Result, nil
+	}
+
+	var parsed callerIdentityXMLResponse
+	if err := xml.Unmarshal(content, &parsed); err != nil {
+		return callerIdentityResult{}, fmt.Errorf("could not parse response '%s': %w", string(data), err)
+	}
+	return parsed.Result, nil
+}
@@ -0,0 +1,223 @@
+package iamsigned
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"golang.org/x/net/websocket"
+)
+
+const realtimeProtocol = "graphql-ws"
+
+type realtimeStartPayload struct {
+	Data       string `json:"data"`
+	Extensions struct {
+		Authorization map[string]string `json:"authorization"`
+	} `json:"extensions"`
+}
+
+// RealtimeClient is an IAM-authenticated connection to the AppSync real-time endpoint, over which GraphQL
+// subscriptions are registered. It watches AppSync keep-alive messages, and fails every subscription when they stop.
+//
+// Requests go through a dedicated WebSocket connection: WithHTTPClient and the transport options other than
+// WithTLSConfig don't apply.
+type RealtimeClient struct {
+	conn     *realtimeConn
+	endpoint string
+	region   string
+	creds    *credentials.Credentials
+	o        *options
+}
+
+// Subscription is a GraphQL subscription registered on a RealtimeClient
+type Subscription struct {
+	ID     string
+	client *RealtimeClient
+	sub    *realtimeSubscription
+}
+
+// DialRealtime connects to the real-time endpoint of the AppSync API whose GraphQL endpoint is given, e.g.
+// https://xxx.appsync-api.eu-west-1.amazonaws.com/graphql (custom domains are supported too).
+func DialRealtime(ctx context.Context, endpoint, region string, creds *credentials.Credentials, opts ...Option) (*RealtimeClient, error) {
+	o := newOptions(opts)
+	endpoint, region, creds, err := o.prepare(endpoint, region, creds)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	c := &RealtimeClient{endpoint: endpoint, region: region, creds: creds, o: o}
+	if err := c.connect(ctx); err != nil {
+		return nil, o.wrapError(err)
+	}
+	go c.conn.readLoop(c.dispatch)
+	return c, nil
+}
+
+// connect opens the WebSocket and performs the connection_init handshake
+func (c *RealtimeClient) connect(ctx context.Context) error {
+	api, err := url.Parse(c.endpoint)
+	if err != nil {
+		return fmt.Errorf("could not parse endpoint: %w", err)
+	}
+	connectURL := *api
+	connectURL.Path = strings.TrimSuffix(api.Path, "/") + "/connect"
+	headers, err := c.authorization(ctx, connectURL.String(), []byte("{}"))
+	if err != nil {
+		return err
+	}
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("could not encode authorization: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("header", base64.StdEncoding.EncodeToString(encodedHeaders))
+	query.Set("payload", base64.StdEncoding.EncodeToString([]byte("{}")))
+	realtime := realtimeURL(api)
+	realtime.RawQuery = query.Encode()
+
+	config, err := websocket.NewConfig(realtime.String(), "https://"+api.Host)
+	if err != nil {
+		return fmt.Errorf("could not configure connection: %w", err)
+	}
+	config.Protocol = []string{realtimeProtocol}
+	config.TlsConfig = c.o.transport.tlsConfig
+	c.conn, err = dialRealtime(ctx, config)
+	return err
+}
+
+// realtimeURL derives the real-time endpoint from the GraphQL one
+func realtimeURL(api *url.URL) *url.URL {
+	u := *api
+	u.Scheme = "wss"
+	if strings.Contains(u.Host, ".appsync-api.") {
+		u.Host = strings.Replace(u.Host, ".appsync-api.", ".appsync-realtime-api.", 1)
+	} else {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/realtime"
+	}
+	return &u
+}
+
+// authorization signs a POST of payload to target, and returns the headers AppSync expects in the
+// authorization extension
+func (c *RealtimeClient) authorization(ctx context.Context, target string, payload []byte) (map[string]string, error) {
+	return realtimeAuthorization(ctx, target, payload, c.region, c.creds, c.o)
+}
+
+// realtimeAuthorization signs a POST of payload to target, and returns the signed headers, as the AppSync real-time
+// endpoints expect them
+func realtimeAuthorization(ctx context.Context, target string, payload []byte, region string, creds *credentials.Credentials, o *options) (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, text/javascript")
+	req.Header.Set("Content-Encoding", "amz-1.0")
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if err := signRequest(ctx, req, bytes.NewReader(payload), AppSyncService, region, creds, o); err != nil {
+		return nil, err
+	}
+	// the handshake carries the signature under its own key, even when WithAuthorizationHeader renamed the header
+	authorization := req.Header.Get(authorizationHeader)
+	if name := o.authorizationHeader; authorization == "" && name != "" {
+		authorization = req.Header.Get(name)
+	}
+
+	headers := map[string]string{
+		"accept":           req.Header.Get("Accept"),
+		"content-encoding": req.Header.Get("Content-Encoding"),
+		"content-type":     req.Header.Get("Content-Type"),
+		"host":             req.URL.Host,
+		"x-amz-date":       req.Header.Get("X-Amz-Date"),
+		"Authorization":    authorization,
+	}
+	if token := req.Header.Get("X-Amz-Security-Token"); token != "" {
+		headers["X-Amz-Security-Token"] = token
+	}
+	return headers, nil
+}
+
+// Subscribe registers a subscription. payload is the GraphQL request, e.g.
+// {"query": "subscription { onCreatePost { id } }", "variables": {}}. Events are delivered on the subscription
+// channel until it's closed, the server completes it, or the connection fails.
+func (c *RealtimeClient) Subscribe(ctx context.Context, payload []byte) (*Subscription, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, c.o.wrapError(err)
+	}
+	headers, err := c.authorization(ctx, c.endpoint, payload)
+	if err != nil {
+		return nil, c.o.wrapError(err)
+	}
+	start := realtimeStartPayload{Data: string(payload)}
+	start.Extensions.Authorization = headers
+	encoded, err := json.Marshal(start)
+	if err != nil {
+		return nil, c.o.wrapError(fmt.Errorf("could not encode subscription: %w", err))
+	}
+
+	sub, err := c.conn.subscribe(ctx, id, realtimeMessage{ID: id, Type: "start", Payload: encoded}, "stop")
+	if err != nil {
+		return nil, c.o.wrapError(err)
+	}
+	return &Subscription{ID: id, client: c, sub: sub}, nil
+}
+
+// Events returns the channel subscription events are delivered on
+func (s *Subscription) Events() <-chan SubscriptionEvent {
+	return s.sub.events
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() error {
+	if !s.client.conn.remove(s.ID) {
+		return nil
+	}
+	return s.client.conn.send(realtimeMessage{ID: s.ID, Type: "stop"})
+}
+
+// Err returns the error that ended the connection, if any
+func (c *RealtimeClient) Err() error {
+	return c.conn.Err()
+}
+
+// Close closes the connection, ending every subscription
+func (c *RealtimeClient) Close() error {
+	return c.conn.Close()
+}
+
+// dispatch handles a message received on the connection
+func (c *RealtimeClient) dispatch(msg realtimeMessage) error {
+	switch msg.Type {
+	case "start_ack":
+		c.conn.acknowledge(msg.ID, nil)
+	case "data":
+		var payload struct {
+			Data   json.RawMessage `json:"data"`
+			Errors json.RawMessage `json:"errors"`
+		}
+		event := SubscriptionEvent{}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			event.Err = fmt.Errorf("could not parse subscription data: %w", err)
+		} else {
+			errs, err := newGraphQLErrors(payload.Errors)
+			event.Data, event.Err = payload.Data, errs.orError(err)
+		}
+		c.conn.deliver(msg.ID, event)
+	case "error":
+		if !c.conn.acknowledge(msg.ID, realtimeError(msg)) {
+			c.conn.deliver(msg.ID, SubscriptionEvent{Err: realtimeError(msg)})
+		}
+	case "complete":
+		c.conn.remove(msg.ID)
+	case "connection_error":
+		return fmt.Errorf("realtime connection error: %w", realtimeError(msg))
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package iamsigned
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"golang.org/x/net/websocket"
+)
+
+var testCreds = credentials.NewStaticCredentials("AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "")
+
+// fakeEvents is the number of data messages fakeRealtime sends for each subscription, enough to fill its channel
+const fakeEvents = 64
+
+// fakeRealtime is an AppSync real-time endpoint, acknowledging every subscription and sending it data messages
+type fakeRealtime struct {
+	*httptest.Server
+	// ackType is the type of the message acknowledging a subscription
+	ackType string
+	// data is the message sent for each event
+	data func(id string) realtimeMessage
+
+	mu       sync.Mutex
+	received []realtimeMessage
+	stopped  map[string]chan struct{}
+}
+
+func newFakeRealtime(t *testing.T, ackType string, data func(id string) realtimeMessage) *fakeRealtime {
+	f := &fakeRealtime{ackType: ackType, data: data, stopped: make(map[string]chan struct{})}
+	f.Server = httptest.NewTLSServer(websocket.Server{
+		// accept the first subprotocol, whatever the origin
+		Handshake: func(config *websocket.Config, _ *http.Request) error {
+			config.Protocol = config.Protocol[:1]
+			return nil
+		},
+		Handler: f.serve,
+	})
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeRealtime) serve(conn *websocket.Conn) {
+	var writeMu sync.Mutex
+	send := func(msg realtimeMessage) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return websocket.JSON.Send(conn, msg)
+	}
+	for {
+		var msg realtimeMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.received = append(f.received, msg)
+		stop, ok := f.stopped[msg.ID]
+		if !ok {
+			stop = make(chan struct{})
+			f.stopped[msg.ID] = stop
+		}
+		f.mu.Unlock()
+
+		switch msg.Type {
+		case "connection_init":
+			send(realtimeMessage{Type: "connection_ack", ConnectionTimeoutMs: 60000})
+		case "start", "subscribe":
+			send(realtimeMessage{ID: msg.ID, Type: f.ackType})
+			go func(id string) {
+				for i := 0; i < fakeEvents; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if send(f.data(id)) != nil {
+						return
+					}
+				}
+			}(msg.ID)
+		case "stop", "unsubscribe":
+			close(stop)
+		}
+	}
+}
+
+// messages returns the messages the server received
+func (f *fakeRealtime) messages() []realtimeMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]realtimeMessage(nil), f.received...)
+}
+
+// trust is the option trusting the certificate of the server
+func (f *fakeRealtime) trust() Option {
+	return trusting(f.Server)
+}
+
+// trusting is the option trusting the certificate of a TLS test server
+func trusting(server *httptest.Server) Option {
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	return WithTLSConfig(&tls.Config{RootCAs: pool})
+}
+
+func graphQLData(id string) realtimeMessage {
+	return realtimeMessage{ID: id, Type: "data", Payload: json.RawMessage(`{"data":{"onCreatePost":{"id":"1"}}}`)}
+}
+
+func TestSubscriptionEvents(t *testing.T) {
+	server := newFakeRealtime(t, "start_ack", graphQLData)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := DialRealtime(ctx, server.URL+"/graphql", "eu-west-1", testCreds, server.trust())
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer client.Close()
+	sub, err := client.Subscribe(ctx, []byte(`{"query":"subscription { onCreatePost { id } }"}`))
+	if err != nil {
+		t.Fatalf("could not subscribe: %v", err)
+	}
+
+	event := <-sub.Events()
+	if event.Err != nil || string(event.Data) != `{"onCreatePost":{"id":"1"}}` {
+		t.Errorf("got event %s (%v)", event.Data, event.Err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("could not close subscription: %v", err)
+	}
+	for range sub.Events() {
+		// drained until closed
+	}
+}
+
+// TestSubscriptionCloseWhileDelivering closes subscriptions while data keeps coming, which must never send on their
+// closed channel. Run with -race.
+func TestSubscriptionCloseWhileDelivering(t *testing.T) {
+	server := newFakeRealtime(t, "start_ack", graphQLData)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := DialRealtime(ctx, server.URL+"/graphql", "eu-west-1", testCreds, server.trust())
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 50; i++ {
+		sub, err := client.Subscribe(ctx, []byte(`{"query":"subscription { onCreatePost { id } }"}`))
+		if err != nil {
+			t.Fatalf("could not subscribe %d: %v", i, err)
+		}
+		<-sub.Events()
+		// the read loop is blocked delivering to the full channel, or about to
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sub.Close()
+		}()
+		go func() {
+			defer wg.Done()
+			for range sub.Events() {
+			}
+		}()
+		wg.Wait()
+	}
+	if err := client.Err(); err != nil {
+		t.Errorf("connection failed: %v", err)
+	}
+}
+
+func TestSubscriptionAuthorizationWithRenamedHeader(t *testing.T) {
+	server := newFakeRealtime(t, "start_ack", graphQLData)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := DialRealtime(ctx, server.URL+"/graphql", "eu-west-1", testCreds, server.trust(),
+		WithAuthorizationHeader("X-Authorization"))
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer client.Close()
+	sub, err := client.Subscribe(ctx, []byte(`{"query":"subscription { onCreatePost { id } }"}`))
+	if err != nil {
+		t.Fatalf("could not subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	for _, msg := range server.messages() {
+		if msg.Type != "start" {
+			continue
+		}
+		var start realtimeStartPayload
+		if err := json.Unmarshal(msg.Payload, &start); err != nil {
+			t.Fatalf("could not parse start payload: %v", err)
+		}
+		if authorization := start.Extensions.Authorization["Authorization"]; !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 ") {
+			t.Errorf("got authorization %q, want a signature", authorization)
+		}
+		return
+	}
+	t.Error("no start message received")
+}
@@ -0,0 +1,45 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// WithTimeout bounds each call, retries and backoff delays included, on top of the deadline of its context. Given to
+// NewClient it is the default of every call of the client, which a per-call WithTimeout replaces. Requests are signed
+// again for every attempt, so a late retry never carries a stale signature.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// withTimeout derives the context of a call from ctx and the timeout
+func (o *options) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// waitDeadline fails right away when the context deadline expires before delay, instead of sleeping only to fail
+func waitDeadline(ctx context.Context, delay time.Duration) error {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return fmt.Errorf("retry delay of %s exceeds the deadline: %w", delay, context.DeadlineExceeded)
+	}
+	return nil
+}
+
+// cancelOnClose releases the context of a call once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,84 @@
+package iamsigned
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Tracer observes calls, e.g. to record them as spans: see the iamsignedotel subpackage for OpenTelemetry
+type Tracer interface {
+	// StartCall runs before the first attempt of a call. The returned context is the one the request is sent with,
+	// and end runs once the call completes (for AppSync, once the GraphQL response is parsed; for streamed
+	// responses, once the body is returned).
+	StartCall(ctx context.Context, call Call) (_ context.Context, end func(CallResult))
+	// Inject adds propagation headers to every attempt, before it's signed
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Call describes a call being made
+type Call struct {
+	Service  AWSService
+	Method   string
+	Endpoint string
+	Region   string
+}
+
+// CallResult describes how a call went
+type CallResult struct {
+	// StatusCode is the status of the last response, 0 when none was received
+	StatusCode int
+	// Attempts is the number of attempts made
+	Attempts int
+	// GraphQLErrors is the number of GraphQL errors of the response
+	GraphQLErrors int
+	Err           error
+}
+
+// WithTracer reports every call to tracer. Tracers add up, e.g. to both record OpenTelemetry spans and propagate the
+// X-Ray trace header.
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		if tracer != nil {
+			o.tracers = append(o.tracers, tracer)
+		}
+	}
+}
+
+// startCall notifies the tracer that a call starts. Nested calls (e.g. the delivery of an AppSync request) are part of
+// the outer one.
+func (o *options) startCall(ctx context.Context, service AWSService, endpoint, region, method string) (context.Context, func(error)) {
+	tracers := o.tracers
+	if o.logger != nil {
+		tracers = append([]Tracer{&callLogger{logger: o.logger, levels: o.logLevels()}}, tracers...)
+	}
+	if len(tracers) == 0 || o.tracing {
+		return ctx, func(error) {}
+	}
+	o.tracing = true
+	call := Call{Service: service, Method: method, Endpoint: endpoint, Region: region}
+	ends := make([]func(CallResult), len(tracers))
+	for i, tracer := range tracers {
+		ctx, ends[i] = tracer.StartCall(ctx, call)
+	}
+	return ctx, func(err error) {
+		result := CallResult{Attempts: o.attempt, Err: err}
+		if o.response != nil {
+			result.StatusCode = o.response.StatusCode
+		}
+		var graphQLErrors *GraphQLErrors
+		if errors.As(err, &graphQLErrors) {
+			result.GraphQLErrors = len(graphQLErrors.Errors)
+		}
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](result)
+		}
+	}
+}
+
+// injectTrace adds the tracer propagation headers to the request
+func (o *options) injectTrace(ctx context.Context, req *http.Request) {
+	for _, tracer := range o.tracers {
+		tracer.Inject(ctx, req.Header)
+	}
+}
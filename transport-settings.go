@@ -0,0 +1,114 @@
+package iamsigned
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// transportSettings customize the transport requests are sent through. They are compared to share transports, so
+// they only hold values created once per Option.
+type transportSettings struct {
+	tlsConfig *tls.Config
+	proxy     *url.URL
+	dialer    *dialer
+}
+
+// dialer wraps a dial function, as functions can't be compared
+type dialer struct {
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// transportCache holds the pooled client built for the settings of the calls using a transport Option. It is owned by
+// the Option, so it goes away with it, and keeps a single client: options reused together always build the same
+// settings.
+type transportCache struct {
+	mu       sync.Mutex
+	settings transportSettings
+	pooled   *http.Client
+}
+
+// WithTLSConfig sends requests through a transport using config for TLS connections, e.g. to trust a private CA
+// bundle (RootCAs) behind an inspection proxy, or to present a client certificate (Certificates) to an API Gateway
+// custom domain with mutual TLS:
+//
+//	pool := x509.NewCertPool()
+//	pool.AppendCertsFromPEM(caBundle)
+//	client := iamsigned.NewClient(endpoint, region, creds, iamsigned.WithTLSConfig(&tls.Config{RootCAs: pool}))
+//
+// Given to NewClient, the client transport uses it. Given to a call, the transport is shared by the calls using the
+// same Option, so reuse it to reuse connections. Transport options can't be combined with WithHTTPClient or
+// WithPublicKeyPins.
+func WithTLSConfig(config *tls.Config) Option {
+	config = config.Clone()
+	cache := &transportCache{}
+	return func(o *options) {
+		o.transport.tlsConfig = config
+		o.transportCache = cache
+	}
+}
+
+// WithProxy sends requests through the proxy at proxyURL (e.g. "http://proxy.corp:3128", with user:password@ for
+// basic auth, or "socks5://127.0.0.1:1080"), instead of the one set in the HTTPS_PROXY and NO_PROXY variables. Like
+// WithTLSConfig, it applies to the client transport when given to NewClient.
+func WithProxy(proxyURL string) Option {
+	u, err := url.Parse(proxyURL)
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = fmt.Errorf("missing scheme or host")
+	}
+	if err != nil {
+		return func(o *options) {
+			o.err = fmt.Errorf("invalid proxy URL '%s': %w", proxyURL, err)
+		}
+	}
+	cache := &transportCache{}
+	return func(o *options) {
+		o.transport.proxy = u
+		o.transportCache = cache
+	}
+}
+
+// WithDialContext opens the connections of the transport with dial, e.g. to tunnel them, go through a peering hop or
+// use a custom DNS resolver. Like WithTLSConfig, it applies to the client transport when given to NewClient.
+func WithDialContext(dial func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	d := &dialer{dial: dial}
+	cache := &transportCache{}
+	return func(o *options) {
+		o.transport.dialer = d
+		o.transportCache = cache
+	}
+}
+
+// newTransport clones http.DefaultTransport, keeping more idle connections, and applies the settings
+func (s transportSettings) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = clientIdleConns
+	transport.MaxIdleConnsPerHost = clientIdleConns
+	if s.tlsConfig != nil {
+		transport.TLSClientConfig = s.tlsConfig
+	}
+	if s.proxy != nil {
+		transport.Proxy = http.ProxyURL(s.proxy)
+	}
+	if s.dialer != nil {
+		transport.DialContext = s.dialer.dial
+	}
+	return transport
+}
+
+// client returns the pooled client of the settings, replacing the cached one when they changed
+func (c *transportCache) client(s transportSettings) *http.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pooled == nil || c.settings != s {
+		if c.pooled != nil {
+			c.pooled.CloseIdleConnections()
+		}
+		c.settings, c.pooled = s, &http.Client{Transport: s.newTransport()}
+	}
+	return c.pooled
+}
@@ -0,0 +1,74 @@
+package iamsigned
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTLSConfigClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	trust := trusting(server)
+
+	if _, err := APIGatewayWithContext(context.Background(), nil, server.URL, "eu-west-1", http.MethodGet, testCreds,
+		trust); err != nil {
+		t.Fatalf("could not call with the trusted CA: %v", err)
+	}
+	first, _ := newOptions([]Option{trust}).client()
+	second, _ := newOptions([]Option{trust}).client()
+	if first != second {
+		t.Error("the calls using the same option got different clients")
+	}
+	if other, _ := newOptions([]Option{trusting(server)}).client(); other == first {
+		t.Error("the calls using different options share a client")
+	}
+}
+
+func TestProxyAndDialContext(t *testing.T) {
+	proxied := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a forward proxy gets the absolute URL of the target
+		if r.URL.Host == "api.example.com" {
+			proxied++
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+	dials := 0
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dials++
+		return (&net.Dialer{}).DialContext(ctx, network, proxy.Listener.Addr().String())
+	}
+
+	tests := []struct {
+		name   string
+		option Option
+		count  *int
+	}{
+		{"WithProxy", WithProxy(proxy.URL), &proxied},
+		{"WithDialContext", WithDialContext(dial), &dials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				if _, err := APIGatewayWithContext(context.Background(), nil, "http://api.example.com/prod",
+					"eu-west-1", http.MethodGet, testCreds, tt.option); err != nil {
+					t.Fatalf("could not call: %v", err)
+				}
+			}
+			if *tt.count == 0 {
+				t.Error("the option was not applied")
+			}
+			first, _ := newOptions([]Option{tt.option}).client()
+			second, _ := newOptions([]Option{tt.option}).client()
+			if first != second {
+				t.Error("the calls using the same option got different clients")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package iamsigned
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// Transport is an http.RoundTripper signing every outgoing request for a service and region, so any *http.Client
+// (and the GraphQL or REST clients built on it) can call IAM-protected AppSync or API Gateway endpoints:
+//
+//	httpClient := &http.Client{Transport: iamsigned.NewTransport(iamsigned.AppSyncService, region, creds, nil)}
+//
+// Request bodies are read in memory to be hashed. Only the options about signing and headers apply (e.g.
+// WithSigner, WithSigningTime, WithHeader, WithAuthorizationHeader, WithRegionResolver): the response is returned
+// as is, whatever its status.
+type Transport struct {
+	service AWSService
+	region  string
+	creds   *credentials.Credentials
+	base    http.RoundTripper
+	opts    []Option
+}
+
+// NewTransport creates a Transport sending the signed requests through base, or http.DefaultTransport when nil.
+// Nil credentials fall back to the default credentials at request time.
+func NewTransport(service AWSService, region string, creds *credentials.Credentials, base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{service: service, region: region, creds: creds, base: base, opts: opts}
+}
+
+// RoundTrip signs a copy of req and sends it
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	o := newOptions(t.opts)
+	payload, err := readRequestBody(req)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+	_, region, creds, err := o.prepare(req.URL.String(), t.region, t.creds)
+	if err != nil {
+		return nil, o.wrapError(err)
+	}
+
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(payload))
+	signed.ContentLength = int64(len(payload))
+	o.applyHeaders(signed)
+	o.injectTrace(req.Context(), signed)
+	if err := signRequest(req.Context(), signed, bytes.NewReader(payload), t.service, region, creds, o); err != nil {
+		return nil, o.wrapError(err)
+	}
+	start := time.Now()
+	response, err := t.base.RoundTrip(signed)
+	o.afterResponse(req.Context(), signed, response, err, time.Since(start))
+	return response, err
+}
+
+// readRequestBody reads and closes the body of req
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read request body: %w", err)
+	}
+	return payload, nil
+}
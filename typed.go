@@ -0,0 +1,75 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// AppSyncQueryAs sends req through the client (a *Client, or a fake such as iamsignedtest.Fake), and unmarshals the
+// data of the response into a T. Like AppSync, the data is still decoded when the response also holds GraphQL errors.
+func AppSyncQueryAs[T any](ctx context.Context, client Sender, req GraphQLRequest, opts ...Option) (T, error) {
+	var result T
+	data, err := client.AppSyncQuery(ctx, req, opts...)
+	if decodeErr := decodeData(data, &result); decodeErr != nil && err == nil {
+		err = decodeErr
+	}
+	return result, err
+}
+
+// AppSyncFieldAs does the same as AppSyncQueryAs, but only decodes the given top-level field of the data, e.g.
+// "getUser" for {"data": {"getUser": {...}}}
+func AppSyncFieldAs[T any](ctx context.Context, client Sender, req GraphQLRequest, field string, opts ...Option) (T, error) {
+	var result T
+	data, err := client.AppSyncQuery(ctx, req, opts...)
+	if isNull(data) {
+		return result, err
+	}
+
+	var fields map[string]json.RawMessage
+	if decodeErr := json.Unmarshal(data, &fields); decodeErr != nil {
+		if err == nil {
+			err = fmt.Errorf("could not decode response data: %w", decodeErr)
+		}
+		return result, err
+	}
+	value, ok := fields[field]
+	if !ok && err == nil {
+		return result, fmt.Errorf("field '%s' not found in response data", field)
+	}
+	if decodeErr := decodeData(value, &result); decodeErr != nil && err == nil {
+		err = decodeErr
+	}
+	return result, err
+}
+
+// APIGatewayAs sends payload through the client to API Gateway, and unmarshals the JSON response into a T
+func APIGatewayAs[T any](ctx context.Context, client Sender, payload []byte, method string, opts ...Option) (T, error) {
+	var result T
+	body, err := client.APIGateway(ctx, payload, method, opts...)
+	if err != nil {
+		return result, err
+	}
+	if len(body) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("could not decode response: '%s': %w", snippet(body), err)
+	}
+	return result, nil
+}
+
+// decodeData unmarshals GraphQL data into v, leaving it untouched when the data is null
+func decodeData(data json.RawMessage, v interface{}) error {
+	if isNull(data) {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("could not decode response data: %w", err)
+	}
+	return nil
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
@@ -0,0 +1,33 @@
+package iamsigned
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Warmup opens a connection to endpoint ahead of time, so the TLS handshake is not paid by the first real request.
+// It sends a cheap unsigned OPTIONS request, ignores the response status and puts the connection back in the idle
+// pool of the HTTP client in use.
+//
+// It is best-effort: it only helps when the transport keeps idle connections (e.g. http.DefaultTransport), and a
+// connection the server closes before the first request is simply re-established. Cancel ctx to bound its duration.
+func Warmup(ctx context.Context, endpoint string, opts ...Option) error {
+	o := newOptions(opts)
+	req, err := http.NewRequest(http.MethodOptions, endpoint, nil)
+	if err != nil {
+		return o.wrapError(fmt.Errorf("could not create request: %w", err))
+	}
+
+	client, err := o.client()
+	if err != nil {
+		return o.wrapError(err)
+	}
+	response, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return o.wrapError(fmt.Errorf("could not warm up connection: %w", err))
+	}
+	// the connection is only reused once the body is fully read, which a large body isn't worth
+	discardBody(response)
+	return nil
+}
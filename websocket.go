@@ -0,0 +1,225 @@
+package iamsigned
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"golang.org/x/net/websocket"
+)
+
+// webSocketPresignExpiry is how long the signature of the upgrade request is valid: it's only checked on connect
+const webSocketPresignExpiry = 5 * time.Minute
+
+// ErrWebSocketClosed is returned when using a WebSocket that was closed
+var ErrWebSocketClosed = errors.New("websocket closed")
+
+// WebSocket is a connection to an API Gateway WebSocket API using IAM authorization. The upgrade request is signed in
+// its query string, as browsers and most WebSocket clients can't set headers.
+//
+// With WithMaxAttempts, a read or write failing on a broken connection dials again, signing a new upgrade request,
+// waiting between attempts as configured with WithRetryBackoff; the failed write is sent again once reconnected.
+// API Gateway gives the new connection another connection id, and messages pushed while disconnected are lost.
+// Reconnect does the same on demand.
+//
+// Reads and writes may run concurrently, but reads (and writes) must not run concurrently with each other.
+type WebSocket struct {
+	endpoint string
+	region   string
+	creds    *credentials.Credentials
+	opts     []Option
+	o        *options
+
+	// dialMu serializes reconnections
+	dialMu sync.Mutex
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	// generation counts connections, so a failure is only handled once
+	generation uint64
+	closed     bool
+}
+
+// DialWebSocket connects to an API Gateway WebSocket API, e.g. wss://<api-id>.execute-api.<region>.amazonaws.com/
+// <stage>. Only the options about signing and retries apply: the dedicated connection doesn't go through
+// WithHTTPClient.
+func DialWebSocket(ctx context.Context, endpoint, region string, creds *credentials.Credentials, opts ...Option) (*WebSocket, error) {
+	w := &WebSocket{endpoint: endpoint, region: region, creds: creds, opts: opts, o: newOptions(opts)}
+	conn, err := w.dial(ctx)
+	if err != nil {
+		return nil, w.o.wrapError(err)
+	}
+	w.conn = conn
+	return w, nil
+}
+
+// dial signs an upgrade request and connects
+func (w *WebSocket) dial(ctx context.Context) (*websocket.Conn, error) {
+	u, err := url.Parse(w.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse endpoint: %w", err)
+	}
+	// the signature covers the host, path and query, which are the same whatever the scheme
+	u.Scheme = "https"
+	signed, header, err := Presign(nil, APIGatewayService, u.String(), w.region, http.MethodGet, webSocketPresignExpiry, w.creds, w.opts...)
+	if err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(signed)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse signed URL: %w", err)
+	}
+	target.Scheme = "wss"
+
+	config, err := websocket.NewConfig(target.String(), "https://"+target.Host)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure connection: %w", err)
+	}
+	config.Header = header
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to websocket endpoint: %w", err)
+	}
+	return conn, nil
+}
+
+// ReadMessage waits for the next message, within the deadline of ctx
+func (w *WebSocket) ReadMessage(ctx context.Context) ([]byte, error) {
+	conn, generation, err := w.current()
+	for err == nil {
+		setConnDeadline(ctx, conn.SetReadDeadline)
+		var data []byte
+		if err = websocket.Message.Receive(conn, &data); err == nil {
+			return data, nil
+		}
+		if ctx.Err() != nil {
+			return nil, w.o.wrapError(ctx.Err())
+		}
+		conn, generation, err = w.reconnect(ctx, generation, fmt.Errorf("could not read message: %w", err))
+	}
+	return nil, w.o.wrapError(err)
+}
+
+// ReadJSON reads the next message and decodes it into v
+func (w *WebSocket) ReadJSON(ctx context.Context, v interface{}) error {
+	data, err := w.ReadMessage(ctx)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return w.o.wrapError(fmt.Errorf("could not parse message: %w", err))
+	}
+	return nil
+}
+
+// WriteMessage sends data in a text frame, within the deadline of ctx
+func (w *WebSocket) WriteMessage(ctx context.Context, data []byte) error {
+	conn, generation, err := w.current()
+	for err == nil {
+		setConnDeadline(ctx, conn.SetWriteDeadline)
+		if err = websocket.Message.Send(conn, string(data)); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return w.o.wrapError(ctx.Err())
+		}
+		conn, generation, err = w.reconnect(ctx, generation, fmt.Errorf("could not send message: %w", err))
+	}
+	return w.o.wrapError(err)
+}
+
+// WriteJSON encodes v and sends it
+func (w *WebSocket) WriteJSON(ctx context.Context, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return w.o.wrapError(fmt.Errorf("could not encode message: %w", err))
+	}
+	return w.WriteMessage(ctx, data)
+}
+
+// Reconnect closes the current connection and dials a new one
+func (w *WebSocket) Reconnect(ctx context.Context) error {
+	_, generation, err := w.current()
+	if err == nil {
+		_, _, err = w.reconnect(ctx, generation, nil)
+	}
+	return w.o.wrapError(err)
+}
+
+// Close closes the connection
+func (w *WebSocket) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.conn.Close()
+}
+
+func (w *WebSocket) current() (*websocket.Conn, uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, 0, ErrWebSocketClosed
+	}
+	return w.conn, w.generation, nil
+}
+
+// reconnect replaces the connection of the given generation after it failed with cause. A nil cause reconnects on
+// demand, with a single attempt. When another goroutine already replaced the connection, the new one is returned.
+func (w *WebSocket) reconnect(ctx context.Context, generation uint64, cause error) (*websocket.Conn, uint64, error) {
+	w.dialMu.Lock()
+	defer w.dialMu.Unlock()
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil, 0, ErrWebSocketClosed
+	}
+	if w.generation != generation {
+		defer w.mu.Unlock()
+		return w.conn, w.generation, nil
+	}
+	w.conn.Close()
+	w.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		if cause != nil {
+			if attempt >= w.o.maxAttempts() {
+				return nil, 0, cause
+			}
+			if err := w.o.backoff(ctx, attempt, 0, nil, cause); err != nil {
+				return nil, 0, err
+			}
+		}
+		conn, err := w.dial(ctx)
+		if err != nil {
+			if cause == nil {
+				return nil, 0, err
+			}
+			cause = err
+			continue
+		}
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.closed {
+			conn.Close()
+			return nil, 0, ErrWebSocketClosed
+		}
+		w.conn = conn
+		w.generation++
+		return conn, w.generation, nil
+	}
+}
+
+// setConnDeadline applies the deadline of ctx, if any, to the next read or write
+func setConnDeadline(ctx context.Context, set func(time.Time) error) {
+	deadline, _ := ctx.Deadline()
+	set(deadline)
+}
@@ -0,0 +1,190 @@
+package iamsigned
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	xrayTraceHeader = "X-Amzn-Trace-Id"
+	// lambdaTraceIDKey is the context key aws-lambda-go stores the trace header of the invocation under
+	lambdaTraceIDKey   = "x-amzn-trace-id"
+	lambdaTraceIDEnv   = "_X_AMZN_TRACE_ID"
+	xrayDaemonEnv      = "AWS_XRAY_DAEMON_ADDRESS"
+	defaultXRayDaemon  = "127.0.0.1:2000"
+	xrayDaemonPreamble = "{\"format\": \"json\", \"version\": 1}\n"
+)
+
+// XRayOption customizes the X-Ray integration
+type XRayOption func(*xrayTracer)
+
+// WithXRaySubsegments also sends a subsegment for every sampled call to the X-Ray daemon (AWS_XRAY_DAEMON_ADDRESS, or
+// 127.0.0.1:2000), so the called API shows in the trace map as a node of its own
+func WithXRaySubsegments() XRayOption {
+	return func(t *xrayTracer) {
+		t.subsegments = true
+	}
+}
+
+// WithXRay propagates the X-Ray trace header of the caller to the called service. Inside Lambda, the trace header is
+// read from the invocation context (as set by aws-lambda-go), or the _X_AMZN_TRACE_ID variable. A header already
+// set on the context with ContextWithXRayTraceHeader takes precedence. The header is not signed.
+func WithXRay(opts ...XRayOption) Option {
+	t := &xrayTracer{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return WithTracer(t)
+}
+
+type xrayContextKey struct{}
+
+// ContextWithXRayTraceHeader sets the X-Ray trace header (e.g. "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=
+// 53995c3f42cd8ad8;Sampled=1") calls made with ctx propagate
+func ContextWithXRayTraceHeader(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, xrayContextKey{}, header)
+}
+
+// xrayTraceHeaderFrom returns the trace header of the caller, if any
+func xrayTraceHeaderFrom(ctx context.Context) string {
+	if header, ok := ctx.Value(xrayContextKey{}).(string); ok && header != "" {
+		return header
+	}
+	if header, ok := ctx.Value(lambdaTraceIDKey).(string); ok && header != "" {
+		return header
+	}
+	return os.Getenv(lambdaTraceIDEnv)
+}
+
+type xrayTracer struct {
+	subsegments bool
+}
+
+// StartCall opens a subsegment when they are enabled and the trace is sampled
+func (t *xrayTracer) StartCall(ctx context.Context, call Call) (context.Context, func(CallResult)) {
+	header := parseXRayHeader(xrayTraceHeaderFrom(ctx))
+	if !t.subsegments || header["Root"] == "" || header["Parent"] == "" || header["Sampled"] != "1" {
+		return ctx, func(CallResult) {}
+	}
+
+	id := xraySegmentID()
+	start := time.Now()
+	ctx = ContextWithXRayTraceHeader(ctx, "Root="+header["Root"]+";Parent="+id+";Sampled=1")
+	return ctx, func(result CallResult) {
+		sendXRaySubsegment(header["Root"], header["Parent"], id, call, result, start, time.Now())
+	}
+}
+
+// Inject sets the trace header on the request
+func (t *xrayTracer) Inject(ctx context.Context, header http.Header) {
+	if trace := xrayTraceHeaderFrom(ctx); trace != "" {
+		header.Set(xrayTraceHeader, trace)
+	}
+}
+
+// parseXRayHeader splits a trace header into its fields
+func parseXRayHeader(header string) map[string]string {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(header, ";") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func xraySegmentID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+type xraySubsegment struct {
+	ID        string  `json:"id"`
+	TraceID   string  `json:"trace_id"`
+	ParentID  string  `json:"parent_id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Namespace string  `json:"namespace"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Error     bool    `json:"error,omitempty"`
+	Throttle  bool    `json:"throttle,omitempty"`
+	Fault     bool    `json:"fault,omitempty"`
+	HTTP      struct {
+		Request struct {
+			Method string `json:"method"`
+			URL    string `json:"url"`
+		} `json:"request"`
+		Response struct {
+			Status int `json:"status,omitempty"`
+		} `json:"response"`
+	} `json:"http"`
+	AWS struct {
+		Operation string `json:"operation,omitempty"`
+		Region    string `json:"region,omitempty"`
+		Retries   int    `json:"retries,omitempty"`
+	} `json:"aws"`
+}
+
+// sendXRaySubsegment reports a completed call to the X-Ray daemon. Tracing is best effort: failures are ignored.
+func sendXRaySubsegment(traceID, parentID, id string, call Call, result CallResult, start, end time.Time) {
+	segment := xraySubsegment{
+		ID:        id,
+		TraceID:   traceID,
+		ParentID:  parentID,
+		Name:      call.Endpoint,
+		Type:      "subsegment",
+		Namespace: "aws",
+		StartTime: float64(start.UnixNano()) / 1e9,
+		EndTime:   float64(end.UnixNano()) / 1e9,
+	}
+	if u, err := url.Parse(call.Endpoint); err == nil && u.Host != "" {
+		segment.Name = u.Host
+	}
+	segment.HTTP.Request.Method = call.Method
+	segment.HTTP.Request.URL = call.Endpoint
+	segment.HTTP.Response.Status = result.StatusCode
+	segment.AWS.Operation = call.Service.String()
+	segment.AWS.Region = call.Region
+	if result.Attempts > 1 {
+		segment.AWS.Retries = result.Attempts - 1
+	}
+	switch {
+	case result.StatusCode == http.StatusTooManyRequests:
+		segment.Error, segment.Throttle = true, true
+	case result.StatusCode >= 500 || result.Err != nil && result.StatusCode == 0:
+		segment.Fault = true
+	case result.Err != nil:
+		segment.Error = true
+	}
+
+	document, err := json.Marshal(segment)
+	if err != nil {
+		return
+	}
+	address := os.Getenv(xrayDaemonEnv)
+	if address == "" {
+		address = defaultXRayDaemon
+	}
+	// the variable may hold "tcp:host:port udp:host:port"
+	for _, part := range strings.Fields(address) {
+		if strings.HasPrefix(part, "udp:") {
+			address = strings.TrimPrefix(part, "udp:")
+		}
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.Write(append([]byte(xrayDaemonPreamble), document...))
+}